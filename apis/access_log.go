@@ -0,0 +1,49 @@
+package apis
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Amostragem dos logs de acesso sob carga: em cada segundo os primeiros
+// registros são gravados e, depois deles, apenas um a cada N.
+type accessLogSampler struct {
+	// registros gravados por segundo antes da amostragem, zero grava todos
+	first atomic.Int64
+	// após o limite grava um a cada N registros, zero descarta os demais
+	thereafter atomic.Int64
+	// segundo atual e quantidade de registros nele
+	second atomic.Int64
+	count  atomic.Int64
+}
+
+// Cria a amostragem com os limites informados.
+func newAccessLogSampler(first int, thereafter int) *accessLogSampler {
+	s := &accessLogSampler{}
+	s.set(first, thereafter)
+	return s
+}
+
+// Altera os limites da amostragem sem reiniciar o servidor.
+func (s *accessLogSampler) set(first int, thereafter int) {
+	s.first.Store(int64(first))
+	s.thereafter.Store(int64(thereafter))
+}
+
+// Indica se o log de acesso do instante informado deve ser gravado.
+func (s *accessLogSampler) sample(now time.Time) bool {
+	first := s.first.Load()
+	if first <= 0 {
+		return true
+	}
+	second := now.Unix()
+	if current := s.second.Load(); current != second && s.second.CompareAndSwap(current, second) {
+		s.count.Store(0)
+	}
+	n := s.count.Add(1)
+	if n <= first {
+		return true
+	}
+	thereafter := s.thereafter.Load()
+	return thereafter > 0 && (n-first)%thereafter == 0
+}
@@ -0,0 +1,41 @@
+package apis
+
+import (
+	"api/handlers"
+	"api/interfaces"
+	"api/models"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Configuração da API para ingestão de linhas de log do CloudWatch Logs via AWS Lambda.
+type CloudWatchLogsApiConfig struct {
+	// repositório de dados
+	Repository interfaces.Repository
+	// descarrega a telemetria ao final de cada invocação
+	Flush FlushFunc
+	// regras de mapeamento
+	Rules []models.MappingRule
+}
+
+// Estrutura da API para ingestão de linhas de log do CloudWatch Logs via AWS Lambda.
+type CloudWatchLogsApi struct {
+	// configuração da API
+	config *CloudWatchLogsApiConfig
+}
+
+// Cria uma nova instância da API para ingestão de linhas de log do CloudWatch Logs.
+func NewCloudWatchLogsApi(config *CloudWatchLogsApiConfig) *CloudWatchLogsApi {
+	return &CloudWatchLogsApi{
+		config: config,
+	}
+}
+
+// Inicia a API para ingestão de linhas de log do CloudWatch Logs.
+func (p *CloudWatchLogsApi) Run() {
+	handler := handlers.NewCloudWatchLogsHandler(&handlers.CloudWatchLogsHandlerConfig{
+		Repository: p.config.Repository,
+		Rules:      p.config.Rules,
+	})
+	lambda.Start(withTelemetryFlushNoResult(p.config.Flush, handler.HandleRequest))
+}
@@ -0,0 +1,41 @@
+package apis
+
+import (
+	"api/handlers"
+	"api/interfaces"
+	"api/models"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Configuração da API para ingestão de eventos do EventBridge via AWS Lambda.
+type EventBridgeApiConfig struct {
+	// repositório de dados
+	Repository interfaces.Repository
+	// descarrega a telemetria ao final de cada invocação
+	Flush FlushFunc
+	// regras de mapeamento
+	Rules []models.MappingRule
+}
+
+// Estrutura da API para ingestão de eventos do EventBridge via AWS Lambda.
+type EventBridgeApi struct {
+	// configuração da API
+	config *EventBridgeApiConfig
+}
+
+// Cria uma nova instância da API para ingestão de eventos do EventBridge.
+func NewEventBridgeApi(config *EventBridgeApiConfig) *EventBridgeApi {
+	return &EventBridgeApi{
+		config: config,
+	}
+}
+
+// Inicia a API para ingestão de eventos do EventBridge.
+func (p *EventBridgeApi) Run() {
+	handler := handlers.NewEventBridgeHandler(&handlers.EventBridgeHandlerConfig{
+		Repository: p.config.Repository,
+		Rules:      p.config.Rules,
+	})
+	lambda.Start(withTelemetryFlushNoResult(p.config.Flush, handler.HandleRequest))
+}
@@ -9,8 +9,12 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 // Estrutura do ResponseWriter para capturar o status code das requisições.
@@ -30,6 +34,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Retorna o ResponseWriter original para uso com o http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Sobrescreve o método Write para garantir que o status code seja capturado mesmo quando WriteHeader não é chamado explicitamente.
 func (rw *responseWriter) Write(b []byte) (int, error) {
 	if !rw.wroteHeader {
@@ -42,18 +51,80 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 type HttpApiConfig struct {
 	// endereço do servidor
 	Address string
+	// configuração de TLS, nil usa HTTP sem criptografia
+	TLS *TLSConfig
 	// porta do servidor
 	Port int
+	// endereço do listener administrativo
+	AdminAddress string
+	// porta do listener administrativo, zero desativa
+	AdminPort int
+	// handler das métricas servido em /metrics no listener administrativo
+	MetricsHandler http.Handler
 	// repositório de dados
 	Repository interfaces.Repository
+	// tempo limite para escrita da exportação em streaming
+	ExportWriteTimeout time.Duration
+	// rejeita a exportação sem status code, que percorre a tabela inteira com Scan
+	ExportRequireStatusCode bool
+	// URL absoluta onde a documentação dos erros é servida, vazio usa os caminhos a partir da raiz
+	ErrorBaseURL string
+	// verificações de saúde adicionais à do repositório
+	HealthChecks []handlers.HealthCheck
+	// período em que a readiness falha antes de recusar novas conexões no encerramento
+	ShutdownDrain time.Duration
+	// tempo limite para as requisições em andamento terminarem no encerramento
+	ShutdownTimeout time.Duration
+	// logs de acesso gravados por segundo antes da amostragem, zero grava todos
+	AccessLogSampleFirst int
+	// após o limite grava um a cada N logs de acesso, zero descarta os demais
+	AccessLogSampleThereafter int
+	// recarrega as configurações ao receber SIGHUP, nil desativa a recarga
+	Reload func() (*HttpApiSettings, error)
+	// arquivo de configuração observado para recarga automática
+	ConfigFile string
+	// intervalo de verificação de alterações no arquivo, zero desativa a observação
+	ConfigWatchInterval time.Duration
 }
 
+// Configurações da API que podem ser alteradas sem reiniciar o servidor.
+type HttpApiSettings struct {
+	// tempo de expiração dos registros
+	RecordTTL time.Duration
+	// tempo limite para escrita da exportação em streaming
+	ExportWriteTimeout time.Duration
+	// período de drenagem no encerramento
+	ShutdownDrain time.Duration
+	// tempo limite para as requisições em andamento no encerramento
+	ShutdownTimeout time.Duration
+	// logs de acesso gravados por segundo antes da amostragem e, depois dele, um a cada N
+	AccessLogSampleFirst      int
+	AccessLogSampleThereafter int
+}
+
+// Tempo limite padrão para as requisições em andamento no encerramento.
+const defaultShutdownTimeout = 30 * time.Second
+
 // Estrutura da API para servidor HTTP.
 type HttpApi struct {
 	// servidor HTTP
 	server *http.Server
+	// servidor do listener administrativo, nil se desativado
+	adminServer *http.Server
 	// configuração da API
 	config *HttpApiConfig
+	// handler das rotas
+	handler *handlers.HttpHandler
+	// certificados do servidor quando o TLS está habilitado
+	tls *tlsReloader
+	// amostragem dos logs de acesso, alterável sem reiniciar
+	accessLog *accessLogSampler
+	// período de drenagem e tempo limite do encerramento, alteráveis sem reiniciar
+	shutdownDrain   atomic.Int64
+	shutdownTimeout atomic.Int64
+	// solicita o encerramento do servidor
+	done     chan struct{}
+	doneOnce sync.Once
 }
 
 // Cria uma nova instância da API para servidor HTTP.
@@ -66,13 +137,27 @@ func NewHttpApi(config *HttpApiConfig) *HttpApi {
 		WriteTimeout:   30 * time.Second,
 	}
 	h := &HttpApi{
-		server: server,
-		config: config,
+		server:    server,
+		config:    config,
+		accessLog: newAccessLogSampler(config.AccessLogSampleFirst, config.AccessLogSampleThereafter),
+		done:      make(chan struct{}),
 	}
+	if config.AdminPort > 0 && config.MetricsHandler != nil {
+		router := http.NewServeMux()
+		router.Handle("GET /metrics", config.MetricsHandler)
+		h.adminServer = &http.Server{
+			Addr:              fmt.Sprintf("%s:%d", config.AdminAddress, config.AdminPort),
+			Handler:           router,
+			ReadHeaderTimeout: 10 * time.Second,
+			WriteTimeout:      30 * time.Second,
+		}
+	}
+	h.setShutdownTimeouts(config.ShutdownDrain, config.ShutdownTimeout)
 	return h
 }
 
 // Middleware para logar todas as requests, inclusive 404 e coletar metricas.
+// Sob carga os logs de acesso são amostrados, exceto os das respostas com erro no servidor.
 func (p *HttpApi) basicMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -82,50 +167,230 @@ func (p *HttpApi) basicMiddleware(next http.Handler) http.Handler {
 		}
 		next.ServeHTTP(rw, r)
 		duration := time.Since(start)
-		slog.InfoContext(
-			r.Context(),
-			fmt.Sprintf("request duration {%dms} status code {%d} method {%s} path {%s} remote address {%s} agent {%s}",
-				duration.Milliseconds(),
-				rw.statusCode,
-				r.Method,
-				r.URL.Path,
-				r.RemoteAddr,
-				r.UserAgent(),
-			),
-		)
+		if rw.statusCode < http.StatusInternalServerError && !p.accessLog.sample(start) {
+			return
+		}
+		attrs := []slog.Attr{
+			slog.Int64("duration_ms", duration.Milliseconds()),
+			slog.Int("status_code", rw.statusCode),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("remote_address", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+		}
+		if identity := handlers.CallerIdentity(r.Context()); identity != "" {
+			attrs = append(attrs, slog.String("caller", identity))
+		}
+		slog.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
 	})
 }
 
+// Nomeia o span do servidor pelo método e pela rota (ex.: GET /eventos/{id}),
+// ou apenas pelo método quando nenhuma rota corresponde à requisição.
+func spanName(_ string, r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.Method
+}
+
 // Inicia a API para servidor HTTP.
-func (p *HttpApi) Run() {
+// Retorna erro se não for possível carregar os certificados do TLS.
+func (p *HttpApi) Run() error {
+	if p.config.TLS != nil {
+		reloader, err := newTLSReloader(p.config.TLS)
+		if err != nil {
+			return err
+		}
+		p.tls = reloader
+		p.server.TLSConfig = reloader.serverConfig()
+	}
 	// deve inicializar um context com cancelamento para receber sinais de término
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 	// configura o handler
 	router := http.NewServeMux()
-	handler := handlers.NewHttpHandler(&handlers.HttpHandlerConfig{
-		Repository: p.config.Repository,
+	p.handler = handlers.NewHttpHandler(&handlers.HttpHandlerConfig{
+		Repository:              p.config.Repository,
+		ExportWriteTimeout:      p.config.ExportWriteTimeout,
+		ExportRequireStatusCode: p.config.ExportRequireStatusCode,
+		ErrorBaseURL:            p.config.ErrorBaseURL,
+		HealthChecks:            p.config.HealthChecks,
 	})
-	handler.HandleRequest(router)
-	p.server.Handler = p.basicMiddleware(router)
+	p.handler.HandleRequest(router)
+	// o span do servidor envolve o log de acesso para que ele seja correlacionado ao rastreamento
+	p.server.Handler = handlers.CallerIdentityMiddleware(otelhttp.NewHandler(p.basicMiddleware(router), "",
+		otelhttp.WithSpanNameFormatter(spanName)))
 	// inicia o servidor em uma goroutine
 	errChan := make(chan error, 1)
 	go func() {
-		slog.Info(fmt.Sprintf("starting server on: %s", p.server.Addr))
+		if p.tls != nil {
+			slog.Info("starting TLS server", "address", p.server.Addr)
+			errChan <- p.server.ListenAndServeTLS("", "")
+			return
+		}
+		slog.Info("starting server", "address", p.server.Addr)
 		errChan <- p.server.ListenAndServe()
 	}()
-	// aguarda o sinal de término
-	select {
-	case err := <-errChan:
-		slog.Error(fmt.Sprintf("server error: %s", err))
-	case <-ctx.Done():
-		stop()
-	}
-	p.server.Shutdown(context.Background())
+	// o listener administrativo é independente do TLS da API
+	if p.adminServer != nil {
+		go func() {
+			slog.Info("starting admin server", "address", p.adminServer.Addr)
+			if err := p.adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				errChan <- fmt.Errorf("admin server, %w", err)
+			}
+		}()
+	}
+	// aguarda o sinal de término, recarregando as configurações quando solicitado
+	reload := p.watchReload(ctx)
+	var serverErr error
+	running := true
+	for running {
+		select {
+		case serverErr = <-errChan:
+			slog.Error("server error", "error", serverErr)
+			running = false
+		case <-ctx.Done():
+			// um segundo sinal encerra o processo imediatamente
+			stop()
+			running = false
+		case <-p.done:
+			running = false
+		case reason := <-reload:
+			p.reload(reason)
+		}
+	}
+	if serverErr == nil {
+		if err := p.gracefulShutdown(); err != nil {
+			slog.Error("server shutdown error", "error", err)
+		}
+	} else {
+		// o servidor da API continua ativo se o erro veio do listener administrativo
+		p.server.Close()
+	}
+	// as métricas continuam disponíveis até o fim do encerramento da API
+	if p.adminServer != nil {
+		p.adminServer.Close()
+	}
 	slog.Info("server exiting")
+	return serverErr
 }
 
-// Encerra a API para servidor HTTP.
+// Altera o período de drenagem e o tempo limite do encerramento.
+func (p *HttpApi) setShutdownTimeouts(drain time.Duration, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	p.shutdownDrain.Store(int64(drain))
+	p.shutdownTimeout.Store(int64(timeout))
+}
+
+// Encerra o servidor de forma graciosa: a readiness falha durante o período de drenagem
+// para que os balanceadores deixem de enviar requisições, as requisições em andamento têm
+// o tempo limite para terminar e as conexões restantes são fechadas à força.
+func (p *HttpApi) gracefulShutdown() error {
+	p.handler.StartShutdown()
+	if drain := time.Duration(p.shutdownDrain.Load()); drain > 0 {
+		slog.Info("draining before closing the listener", "drain", drain.String())
+		time.Sleep(drain)
+	}
+	timeout := time.Duration(p.shutdownTimeout.Load())
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := p.server.Shutdown(ctx); err != nil {
+		slog.Warn("in-flight requests did not finish in time, closing connections", "timeout", timeout.String(), "error", err)
+		return p.server.Close()
+	}
+	return nil
+}
+
+// Observa o SIGHUP e as alterações no arquivo de configuração.
+// Retorna o canal que recebe o motivo de cada recarga solicitada.
+func (p *HttpApi) watchReload(ctx context.Context) <-chan string {
+	reload := make(chan string, 1)
+	if p.config.Reload == nil {
+		return reload
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				select {
+				case reload <- "SIGHUP":
+				default:
+				}
+			}
+		}
+	}()
+	if p.config.ConfigFile != "" && p.config.ConfigWatchInterval > 0 {
+		go p.watchConfigFile(ctx, reload)
+	}
+	return reload
+}
+
+// Verifica periodicamente se o arquivo de configuração foi alterado.
+func (p *HttpApi) watchConfigFile(ctx context.Context, reload chan<- string) {
+	stat := func() (time.Time, int64) {
+		info, err := os.Stat(p.config.ConfigFile)
+		if err != nil {
+			return time.Time{}, -1
+		}
+		return info.ModTime(), info.Size()
+	}
+	modTime, size := stat()
+	ticker := time.NewTicker(p.config.ConfigWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			currentModTime, currentSize := stat()
+			if currentModTime.Equal(modTime) && currentSize == size {
+				continue
+			}
+			modTime, size = currentModTime, currentSize
+			select {
+			case reload <- fmt.Sprintf("file %s changed", p.config.ConfigFile):
+			default:
+			}
+		}
+	}
+}
+
+// Recarrega as configurações, mantendo as atuais se a nova configuração for inválida.
+func (p *HttpApi) reload(reason string) {
+	if p.tls != nil {
+		p.tls.reload(true)
+	}
+	settings, err := p.config.Reload()
+	if err != nil {
+		slog.Error("unable to reload configuration, keeping current settings", "reason", reason, "error", err)
+		return
+	}
+	p.config.Repository.SetTTL(settings.RecordTTL)
+	p.handler.SetExportWriteTimeout(settings.ExportWriteTimeout)
+	p.setShutdownTimeouts(settings.ShutdownDrain, settings.ShutdownTimeout)
+	p.accessLog.set(settings.AccessLogSampleFirst, settings.AccessLogSampleThereafter)
+	slog.Info("configuration reloaded",
+		"reason", reason,
+		"record_ttl", settings.RecordTTL.String(),
+		"export_write_timeout", settings.ExportWriteTimeout.String(),
+		"shutdown_drain", settings.ShutdownDrain.String(),
+		"shutdown_timeout", settings.ShutdownTimeout.String(),
+		"access_log_sample_first", settings.AccessLogSampleFirst,
+		"access_log_sample_thereafter", settings.AccessLogSampleThereafter,
+	)
+}
+
+// Solicita o encerramento gracioso da API para servidor HTTP iniciada com Run.
 func (p *HttpApi) Shutdown() {
-	p.server.Shutdown(context.Background())
+	p.doneOnce.Do(func() {
+		close(p.done)
+	})
 }
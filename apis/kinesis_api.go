@@ -0,0 +1,37 @@
+package apis
+
+import (
+	"api/handlers"
+	"api/interfaces"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Configuração da API para consumo de registros do Kinesis Data Streams via AWS Lambda.
+type KinesisApiConfig struct {
+	// repositório de dados
+	Repository interfaces.Repository
+	// descarrega a telemetria ao final de cada invocação
+	Flush FlushFunc
+}
+
+// Estrutura da API para consumo de registros do Kinesis Data Streams via AWS Lambda.
+type KinesisApi struct {
+	// configuração da API
+	config *KinesisApiConfig
+}
+
+// Cria uma nova instância da API para consumo de registros do Kinesis.
+func NewKinesisApi(config *KinesisApiConfig) *KinesisApi {
+	return &KinesisApi{
+		config: config,
+	}
+}
+
+// Inicia a API para consumo de registros do Kinesis.
+func (p *KinesisApi) Run() {
+	handler := handlers.NewKinesisHandler(&handlers.KinesisHandlerConfig{
+		Repository: p.config.Repository,
+	})
+	lambda.Start(withTelemetryFlush(p.config.Flush, handler.HandleRequest))
+}
@@ -11,6 +11,10 @@ import (
 type LambdaApiConfig struct {
 	// repositório de dados
 	Repository interfaces.Repository
+	// descarrega a telemetria ao final de cada invocação
+	Flush FlushFunc
+	// URL absoluta onde a documentação dos erros é servida, vazio usa o caminho da requisição
+	ErrorBaseURL string
 }
 
 // Estrutura da API para AWS Lambda.
@@ -29,7 +33,8 @@ func NewLambdaApi(config *LambdaApiConfig) *LambdaApi {
 // Inicia a API para AWS Lambda.
 func (p *LambdaApi) Run() {
 	handler := handlers.NewLambdaHandler(&handlers.LambdaHandlerConfig{
-		Repository: p.config.Repository,
+		Repository:   p.config.Repository,
+		ErrorBaseURL: p.config.ErrorBaseURL,
 	})
-	lambda.Start(handler.HandleRequest)
+	lambda.Start(withTelemetryFlush(p.config.Flush, handler.HandleRequest))
 }
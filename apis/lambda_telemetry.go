@@ -0,0 +1,57 @@
+package apis
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	// tempo reservado antes do fim da invocação para o runtime enviar a resposta
+	lambdaFlushReserve = 100 * time.Millisecond
+	// tempo máximo para descarregar a telemetria ao final da invocação
+	lambdaMaxFlushTimeout = 5 * time.Second
+)
+
+// Função que descarrega a telemetria pendente.
+type FlushFunc func(ctx context.Context) error
+
+// Descarrega a telemetria usando o tempo restante da invocação,
+// pois o ambiente de execução é congelado logo após a resposta.
+func flushTelemetry(ctx context.Context, flush FlushFunc) {
+	if flush == nil {
+		return
+	}
+	timeout := lambdaMaxFlushTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline) - lambdaFlushReserve; remaining < timeout {
+			timeout = remaining
+		}
+	}
+	if timeout <= 0 {
+		slog.Warn("no time left to flush telemetry at the end of the invocation")
+		return
+	}
+	// a flush não deve ser interrompida pelo cancelamento do contexto da invocação
+	flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	if err := flush(flushCtx); err != nil {
+		slog.Warn("unable to flush telemetry in time", "timeout", timeout.String(), "error", err)
+	}
+}
+
+// Envolve o handler para descarregar a telemetria ao final de cada invocação.
+func withTelemetryFlush[TIn any, TOut any](flush FlushFunc, handler func(context.Context, TIn) (TOut, error)) func(context.Context, TIn) (TOut, error) {
+	return func(ctx context.Context, input TIn) (TOut, error) {
+		defer flushTelemetry(ctx, flush)
+		return handler(ctx, input)
+	}
+}
+
+// Envolve o handler sem resposta para descarregar a telemetria ao final de cada invocação.
+func withTelemetryFlushNoResult[TIn any](flush FlushFunc, handler func(context.Context, TIn) error) func(context.Context, TIn) error {
+	return func(ctx context.Context, input TIn) error {
+		defer flushTelemetry(ctx, flush)
+		return handler(ctx, input)
+	}
+}
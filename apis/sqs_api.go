@@ -0,0 +1,37 @@
+package apis
+
+import (
+	"api/handlers"
+	"api/interfaces"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Configuração da API para consumo de mensagens do SQS via AWS Lambda.
+type SqsApiConfig struct {
+	// repositório de dados
+	Repository interfaces.Repository
+	// descarrega a telemetria ao final de cada invocação
+	Flush FlushFunc
+}
+
+// Estrutura da API para consumo de mensagens do SQS via AWS Lambda.
+type SqsApi struct {
+	// configuração da API
+	config *SqsApiConfig
+}
+
+// Cria uma nova instância da API para consumo de mensagens do SQS.
+func NewSqsApi(config *SqsApiConfig) *SqsApi {
+	return &SqsApi{
+		config: config,
+	}
+}
+
+// Inicia a API para consumo de mensagens do SQS.
+func (p *SqsApi) Run() {
+	handler := handlers.NewSqsHandler(&handlers.SqsHandlerConfig{
+		Repository: p.config.Repository,
+	})
+	lambda.Start(withTelemetryFlush(p.config.Flush, handler.HandleRequest))
+}
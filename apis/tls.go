@@ -0,0 +1,198 @@
+package apis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Intervalo mínimo entre as verificações de alteração dos certificados.
+const tlsReloadCheckInterval = 10 * time.Second
+
+// Configuração de TLS do servidor HTTP.
+type TLSConfig struct {
+	// arquivo do certificado em PEM
+	CertFile string
+	// arquivo da chave privada em PEM
+	KeyFile string
+	// arquivo com as autoridades certificadoras aceitas nos certificados de cliente
+	ClientCAFile string
+	// modo de verificação do certificado do cliente
+	ClientAuth tls.ClientAuthType
+	// versão mínima do TLS
+	MinVersion uint16
+	// cifras aceitas no TLS 1.2, vazio usa as padrões do Go
+	CipherSuites []uint16
+}
+
+// Converte o modo de verificação do certificado do cliente.
+func ParseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(mode) {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return tls.NoClientCert, fmt.Errorf("unknown client auth mode %q, expected none, request, require, verify_if_given or require_and_verify", mode)
+}
+
+// Converte a versão mínima do TLS.
+func ParseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unsupported TLS version %q, expected 1.2 or 1.3", version)
+}
+
+// Converte os nomes das cifras, aceitando apenas as consideradas seguras pelo Go.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	suites := make([]uint16, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, suite := range tls.CipherSuites() {
+			if suite.Name == strings.TrimSpace(name) {
+				suites = append(suites, suite.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+	}
+	return suites, nil
+}
+
+// Mantém os certificados do servidor e as autoridades dos clientes,
+// recarregando os arquivos quando eles são alterados.
+type tlsReloader struct {
+	// configuração de TLS
+	config *TLSConfig
+	// controla o acesso concorrente aos certificados
+	mu sync.RWMutex
+	// certificado do servidor
+	certificate *tls.Certificate
+	// autoridades certificadoras dos clientes
+	clientCAs *x509.CertPool
+	// data de alteração dos arquivos carregados
+	modTimes map[string]time.Time
+	// última verificação de alteração dos arquivos
+	checked time.Time
+}
+
+// Cria o recarregador carregando os certificados informados.
+func newTLSReloader(config *TLSConfig) (*tlsReloader, error) {
+	r := &tlsReloader{config: config}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Retorna os arquivos monitorados.
+func (r *tlsReloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+	return files
+}
+
+// Carrega os certificados dos arquivos.
+func (r *tlsReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = info.ModTime()
+	}
+	certificate, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load certificate, %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		data, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("unable to read client CA file, %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.config.ClientCAFile)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.certificate = &certificate
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	r.checked = time.Now()
+	return nil
+}
+
+// Recarrega os certificados se algum arquivo foi alterado.
+// Em caso de erro os certificados atuais são mantidos.
+func (r *tlsReloader) reload(force bool) {
+	r.mu.Lock()
+	if !force && time.Since(r.checked) < tlsReloadCheckInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.checked = time.Now()
+	changed := force
+	for _, file := range r.files() {
+		if info, err := os.Stat(file); err == nil && !info.ModTime().Equal(r.modTimes[file]) {
+			changed = true
+		}
+	}
+	r.mu.Unlock()
+	if !changed {
+		return
+	}
+	if err := r.load(); err != nil {
+		slog.Error("unable to reload TLS certificates, keeping current certificates", "error", err)
+		return
+	}
+	slog.Info("TLS certificates reloaded", "cert_file", r.config.CertFile)
+}
+
+// Retorna a configuração de TLS do servidor.
+// A configuração de cada conexão é montada com os certificados atuais.
+func (r *tlsReloader) serverConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion:   r.config.MinVersion,
+		CipherSuites: r.config.CipherSuites,
+		ClientAuth:   r.config.ClientAuth,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	config := base.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.reload(false)
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		current := base.Clone()
+		current.Certificates = []tls.Certificate{*r.certificate}
+		current.ClientCAs = r.clientCAs
+		return current, nil
+	}
+	return config
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Executa os comandos de validação e exibição da configuração.
+func runConfig(args []string) error {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "usage: config <validate|print> [flags]")
+	}
+	if len(args) == 0 {
+		usage()
+		return fmt.Errorf("config requires a subcommand")
+	}
+	fs := flag.NewFlagSet("config "+args[0], flag.ContinueOnError)
+	var format string
+	switch args[0] {
+	case "validate":
+	case "print":
+		fs.StringVar(&format, "format", "json", "output format (json or yaml)")
+	default:
+		usage()
+		return fmt.Errorf("unknown config subcommand {%s}", args[0])
+	}
+	configFlags := newConfigFlags(fs)
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("config %s does not accept arguments", args[0])
+	}
+	if format != "" && format != "json" && format != "yaml" {
+		return fmt.Errorf("unknown format {%s}", format)
+	}
+	cfg, err := loadApplicationConfig(configFlags)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "validate":
+		source := cfg.File
+		if source == "" {
+			source = "(defaults)"
+		}
+		fmt.Printf("configuration %s is valid\n", source)
+	case "print":
+		// exibe a configuração efetiva, após aplicar o ambiente e as flags
+		if format == "yaml" {
+			enc := yaml.NewEncoder(os.Stdout)
+			enc.SetIndent(2)
+			defer enc.Close()
+			return enc.Encode(cfg)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", " ")
+		return enc.Encode(cfg)
+	}
+	return nil
+}
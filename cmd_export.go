@@ -0,0 +1,131 @@
+package main
+
+import (
+	"api/interfaces"
+	"api/models"
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Opções do comando de exportação.
+type exportOptions struct {
+	// início do período
+	from time.Time
+	// fim do período
+	to time.Time
+	// status code dos registros, quando informado usa a consulta pelo índice
+	statusCode *int
+	// quantidade de segmentos paralelos do Scan
+	segments int
+}
+
+// Executa o comando de exportação de eventos para JSONL na saída padrão.
+func runExport(args []string) error {
+	options := &exportOptions{}
+	var from, to, status string
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: export [flags] > out.jsonl")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&from, "from", "", "start date in RFC3339")
+	fs.StringVar(&to, "to", "", "end date in RFC3339 (default now)")
+	fs.StringVar(&status, "status", "", "status code, uses the date-statusCode index instead of a full scan")
+	fs.IntVar(&options.segments, "segments", 4, "number of parallel scan segments")
+	configFlags := newConfigFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("export does not accept arguments")
+	}
+	var err error
+	if from != "" {
+		if options.from, err = time.Parse(time.RFC3339, from); err != nil {
+			return fmt.Errorf("parameter {from} invalid, %w", err)
+		}
+	}
+	options.to = time.Now()
+	if to != "" {
+		if options.to, err = time.Parse(time.RFC3339, to); err != nil {
+			return fmt.Errorf("parameter {to} invalid, %w", err)
+		}
+	}
+	if status != "" {
+		statusCode, err := strconv.Atoi(status)
+		if err != nil {
+			return fmt.Errorf("parameter {status} invalid, %w", err)
+		}
+		options.statusCode = &statusCode
+	}
+	if options.segments < 1 {
+		return fmt.Errorf("segments must be greater than zero")
+	}
+	cfg, err := loadApplicationConfig(configFlags)
+	if err != nil {
+		return err
+	}
+	repository, err := newRepository(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return exportEvents(ctx, options, repository, os.Stdout)
+}
+
+// Exporta os eventos do repositório no formato JSONL.
+func exportEvents(ctx context.Context, options *exportOptions, repository interfaces.Repository, out io.Writer) error {
+	writer := bufio.NewWriter(out)
+	encoder := json.NewEncoder(writer)
+	var mu sync.Mutex
+	var written atomic.Int64
+	stopProgress := reportProgress(func() string {
+		return fmt.Sprintf("exported %d", written.Load())
+	})
+	defer stopProgress()
+	// os segmentos do Scan chamam a função concorrentemente
+	write := func(event *models.Event) error {
+		if event.Date.Before(options.from) || event.Date.After(options.to) {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+		written.Add(1)
+		return nil
+	}
+	var err error
+	if options.statusCode != nil {
+		// as páginas da consulta são gravadas à medida que chegam
+		err = repository.StreamByDate(ctx, options.from, options.to, options.statusCode, func(events []*models.Event) error {
+			for _, event := range events {
+				if err := write(event); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	} else {
+		err = repository.Scan(ctx, options.segments, write)
+	}
+	if flushErr := writer.Flush(); err == nil {
+		err = flushErr
+	}
+	stopProgress()
+	return err
+}
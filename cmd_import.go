@@ -0,0 +1,310 @@
+package main
+
+import (
+	"api/interfaces"
+	"api/models"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Opções do comando de importação.
+type importOptions struct {
+	// arquivo JSONL de origem
+	file string
+	// arquivo de checkpoint
+	checkpoint string
+	// quantidade de lotes gravados em paralelo
+	concurrency int
+	// quantidade de registros por lote
+	batchSize int
+	// apenas valida o arquivo sem gravar
+	dryRun bool
+	// retoma a partir do último checkpoint
+	resume bool
+}
+
+// Lote de linhas lidas do arquivo.
+type importBatch struct {
+	// número da primeira e da última linha do lote
+	first, last int
+	// eventos válidos do lote
+	events []*models.Event
+}
+
+// Contadores de progresso da importação.
+type importProgress struct {
+	read    atomic.Int64
+	saved   atomic.Int64
+	invalid atomic.Int64
+	failed  atomic.Int64
+}
+
+// Controla o checkpoint da importação, que é a última linha
+// a partir da qual todas as anteriores já foram processadas.
+type importCheckpoint struct {
+	mu        sync.Mutex
+	file      string
+	line      int
+	completed map[int]int
+}
+
+// Registra um lote concluído e avança o checkpoint se possível.
+func (c *importCheckpoint) complete(batch *importBatch) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.completed[batch.first] = batch.last
+	for {
+		last, ok := c.completed[c.line+1]
+		if !ok {
+			break
+		}
+		delete(c.completed, c.line+1)
+		c.line = last
+	}
+}
+
+// Grava o checkpoint no arquivo.
+func (c *importCheckpoint) save() error {
+	c.mu.Lock()
+	line := c.line
+	c.mu.Unlock()
+	return os.WriteFile(c.file, []byte(strconv.Itoa(line)), 0640)
+}
+
+// Lê o checkpoint do arquivo, retornando zero se ele não existir.
+func readImportCheckpoint(file string) (int, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// Executa o comando de importação de eventos de um arquivo JSONL.
+func runImport(args []string) error {
+	options := &importOptions{}
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: import [flags] <file.jsonl>")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&options.checkpoint, "checkpoint", "", "checkpoint file (default <file>.checkpoint)")
+	fs.IntVar(&options.concurrency, "concurrency", 4, "number of batches saved in parallel")
+	fs.IntVar(&options.batchSize, "batch-size", 25, "number of records per batch")
+	fs.BoolVar(&options.dryRun, "dry-run", false, "only validate the file")
+	fs.BoolVar(&options.resume, "resume", false, "resume from the last checkpoint")
+	configFlags := newConfigFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("import requires exactly one file")
+	}
+	options.file = fs.Arg(0)
+	if options.checkpoint == "" {
+		options.checkpoint = options.file + ".checkpoint"
+	}
+	if options.concurrency < 1 || options.batchSize < 1 {
+		return fmt.Errorf("concurrency and batch-size must be greater than zero")
+	}
+	cfg, err := loadApplicationConfig(configFlags)
+	if err != nil {
+		return err
+	}
+	repository, err := newRepository(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return importFile(ctx, options, repository)
+}
+
+// Importa os eventos do arquivo JSONL para o repositório.
+func importFile(ctx context.Context, options *importOptions, repository interfaces.Repository) error {
+	f, err := os.Open(options.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	checkpoint := &importCheckpoint{file: options.checkpoint, completed: make(map[int]int)}
+	if options.resume {
+		if checkpoint.line, err = readImportCheckpoint(options.checkpoint); err != nil {
+			return fmt.Errorf("unable to read checkpoint, %w", err)
+		}
+		if checkpoint.line > 0 {
+			fmt.Fprintf(os.Stderr, "resuming after line %d\n", checkpoint.line)
+		}
+	}
+	progress := &importProgress{}
+	stopProgress := reportProgress(func() string {
+		return fmt.Sprintf("read %d saved %d invalid %d failed %d",
+			progress.read.Load(), progress.saved.Load(), progress.invalid.Load(), progress.failed.Load())
+	})
+	defer stopProgress()
+	// grava os lotes em paralelo
+	batches := make(chan *importBatch, options.concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < options.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				if !options.dryRun && len(batch.events) > 0 {
+					failed, err := repository.SaveBatch(ctx, batch.events)
+					if err != nil {
+						progress.failed.Add(int64(len(failed)))
+						progress.saved.Add(int64(len(batch.events) - len(failed)))
+						fmt.Fprintf(os.Stderr, "lines %d-%d: %d records failed, %s\n", batch.first, batch.last, len(failed), err)
+						// o checkpoint não avança após um lote com falha
+						continue
+					}
+					progress.saved.Add(int64(len(batch.events)))
+				}
+				checkpoint.complete(batch)
+			}
+		}()
+	}
+	// lê o arquivo e monta os lotes
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	line := 0
+	batch := &importBatch{first: checkpoint.line + 1}
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	var readErr error
+	for scanner.Scan() {
+		line++
+		if line <= checkpoint.line {
+			continue
+		}
+		if ctx.Err() != nil {
+			readErr = ctx.Err()
+			break
+		}
+		progress.read.Add(1)
+		if event, err := decodeImportLine(scanner.Bytes()); err != nil {
+			progress.invalid.Add(1)
+			fmt.Fprintf(os.Stderr, "line %d: %s\n", line, err)
+		} else if event != nil {
+			batch.events = append(batch.events, event)
+		}
+		batch.last = line
+		if len(batch.events) >= options.batchSize {
+			batches <- batch
+			batch = &importBatch{first: line + 1}
+		}
+		select {
+		case <-ticker.C:
+			if !options.dryRun {
+				checkpoint.save()
+			}
+		default:
+		}
+	}
+	if readErr == nil {
+		readErr = scanner.Err()
+	}
+	if batch.last >= batch.first {
+		batches <- batch
+	}
+	close(batches)
+	wg.Wait()
+	stopProgress()
+	if !options.dryRun {
+		if err := checkpoint.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to save checkpoint, %s\n", err)
+		}
+	}
+	if readErr != nil {
+		return readErr
+	}
+	if progress.invalid.Load() > 0 || progress.failed.Load() > 0 {
+		return fmt.Errorf("%d invalid and %d failed records", progress.invalid.Load(), progress.failed.Load())
+	}
+	// a importação completa não precisa mais do checkpoint
+	if !options.dryRun {
+		os.Remove(options.checkpoint)
+	}
+	return nil
+}
+
+// Decodifica e valida uma linha do arquivo JSONL.
+// Retorna nil para linhas em branco.
+func decodeImportLine(data []byte) (*models.Event, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	event := &models.Event{}
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, err
+	}
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+	// o id é derivado do conteúdo para que uma nova importação não duplique registros
+	if event.Id == "" {
+		event.Id = uuid.NewSHA1(uuid.NameSpaceOID, data).String()
+	}
+	return event, nil
+}
+
+// Faz o parser das flags permitindo que elas sejam informadas após os argumentos posicionais.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	positional := make([]string, 0)
+	for fs.NArg() > 0 {
+		positional = append(positional, fs.Arg(0))
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return err
+		}
+	}
+	return fs.Parse(append([]string{"--"}, positional...))
+}
+
+// Exibe o progresso periodicamente na saída de erro.
+// Retorna a função que encerra o relatório exibindo o estado final.
+func reportProgress(status func() string) func() {
+	done := make(chan struct{})
+	var once sync.Once
+	start := time.Now()
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fmt.Fprintf(os.Stderr, "%s elapsed %s\n", status(), time.Since(start).Round(time.Second))
+			}
+		}
+	}()
+	return func() {
+		once.Do(func() {
+			close(done)
+			fmt.Fprintf(os.Stderr, "%s elapsed %s\n", status(), time.Since(start).Round(time.Second))
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"api/apis"
+	"api/handlers"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Executa o comando que inicia a API no modo servidor HTTP.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: serve [flags]")
+		fs.PrintDefaults()
+	}
+	configFlags := newConfigFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("serve does not accept arguments")
+	}
+	cfg, err := loadApplicationConfig(configFlags)
+	if err != nil {
+		return err
+	}
+	// com o listener administrativo as métricas também são expostas para o Prometheus
+	options := cfg.TelemetryOptions()
+	var metricsHandler http.Handler
+	if cfg.AdminPort > 0 {
+		options.prometheusRegistry = prometheus.NewRegistry()
+		metricsHandler = promhttp.HandlerFor(options.prometheusRegistry, promhttp.HandlerOpts{})
+	}
+	otelShutdown, _, err := setupTelemetry(context.Background(), options, cfg.LogFormat)
+	if err != nil {
+		return err
+	}
+	defer shutdownTelemetry(otelShutdown)
+	if err := setupRepository(context.Background(), cfg); err != nil {
+		return err
+	}
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		return err
+	}
+	api := apis.NewHttpApi(&apis.HttpApiConfig{
+		Address:                 cfg.Address,
+		Port:                    cfg.Port,
+		AdminAddress:            cfg.AdminAddress,
+		AdminPort:               cfg.AdminPort,
+		MetricsHandler:          metricsHandler,
+		TLS:                     tlsConfig,
+		Repository:              cfg.Repository,
+		ExportWriteTimeout:      time.Duration(cfg.ExportWriteTimeoutSeconds) * time.Second,
+		ExportRequireStatusCode: cfg.ExportRequireStatusCode,
+		ErrorBaseURL:            cfg.ErrorBaseURL,
+		HealthChecks: []handlers.HealthCheck{{
+			Name:  "telemetry",
+			Check: telemetryStatus.Check,
+		}, {
+			Name:  "circuit_breaker",
+			Check: cfg.Resilience.Check,
+		}},
+		ShutdownDrain:             time.Duration(cfg.ShutdownDrainSeconds) * time.Second,
+		ShutdownTimeout:           time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second,
+		AccessLogSampleFirst:      cfg.AccessLogSampleFirst,
+		AccessLogSampleThereafter: cfg.AccessLogSampleThereafter,
+		Reload:                    reloadHttpSettings(cfg, configFlags),
+		ConfigFile:                cfg.File,
+		ConfigWatchInterval:       time.Duration(cfg.ConfigWatchSeconds) * time.Second,
+	})
+	err = api.Run()
+	// o repositório é encerrado após as requisições e antes da telemetria,
+	// para que os últimos spans e logs sejam exportados
+	closeRepository(cfg.Repository)
+	return err
+}
+
+// Cria a função de recarga das configurações do servidor HTTP.
+// O nível de log é aplicado ao carregar a configuração e as configurações
+// que exigem reinício são mantidas, gerando um aviso. Os avisos consideram a
+// última configuração carregada, para que cada alteração seja avisada uma vez.
+func reloadHttpSettings(current *Config, configFlags *configFlags) func() (*apis.HttpApiSettings, error) {
+	return func() (*apis.HttpApiSettings, error) {
+		cfg, err := loadApplicationConfig(configFlags)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range current.RestartRequiredChanges(cfg) {
+			slog.Warn("setting changed but requires a restart to take effect", "setting", name)
+		}
+		current = cfg
+		return &apis.HttpApiSettings{
+			RecordTTL:                 time.Duration(cfg.RecordTTLMinutes) * time.Minute,
+			ExportWriteTimeout:        time.Duration(cfg.ExportWriteTimeoutSeconds) * time.Second,
+			ShutdownDrain:             time.Duration(cfg.ShutdownDrainSeconds) * time.Second,
+			ShutdownTimeout:           time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second,
+			AccessLogSampleFirst:      cfg.AccessLogSampleFirst,
+			AccessLogSampleThereafter: cfg.AccessLogSampleThereafter,
+		}, nil
+	}
+}
+
+// Executa o comando que inicia a API no modo AWS Lambda com o tipo de evento informado.
+func runLambda(args []string) error {
+	fs := flag.NewFlagSet("lambda", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: lambda [flags] [http|sqs|kinesis|eventbridge|logs]")
+		fs.PrintDefaults()
+	}
+	configFlags := newConfigFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("lambda accepts at most one event source")
+	}
+	source := "http"
+	if fs.NArg() == 1 {
+		source = fs.Arg(0)
+	}
+	switch source {
+	case "http", "sqs", "kinesis", "eventbridge", "logs":
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown lambda event source {%s}", source)
+	}
+	cfg, err := loadApplicationConfig(configFlags)
+	if err != nil {
+		return err
+	}
+	// com a extensão do coletor os spans e logs são enviados imediatamente para
+	// o coletor local; nos dois modos a telemetria é descarregada ao final de cada invocação
+	options := cfg.TelemetryOptions()
+	options.syncExport = cfg.LambdaTelemetryExport == LambdaTelemetryExtension
+	otelShutdown, otelFlush, err := setupTelemetry(context.Background(), options, cfg.LogFormat)
+	if err != nil {
+		return err
+	}
+	defer shutdownTelemetry(otelShutdown)
+	if err := setupRepository(context.Background(), cfg); err != nil {
+		return err
+	}
+	switch source {
+	case "sqs":
+		apis.NewSqsApi(&apis.SqsApiConfig{
+			Repository: cfg.Repository,
+			Flush:      otelFlush,
+		}).Run()
+	case "kinesis":
+		apis.NewKinesisApi(&apis.KinesisApiConfig{
+			Repository: cfg.Repository,
+			Flush:      otelFlush,
+		}).Run()
+	case "eventbridge":
+		apis.NewEventBridgeApi(&apis.EventBridgeApiConfig{
+			Repository: cfg.Repository,
+			Flush:      otelFlush,
+			Rules:      cfg.EventBridgeMappings,
+		}).Run()
+	case "logs":
+		apis.NewCloudWatchLogsApi(&apis.CloudWatchLogsApiConfig{
+			Repository: cfg.Repository,
+			Flush:      otelFlush,
+			Rules:      cfg.LogsMappings,
+		}).Run()
+	default:
+		apis.NewLambdaApi(&apis.LambdaApiConfig{
+			Repository:   cfg.Repository,
+			Flush:        otelFlush,
+			ErrorBaseURL: cfg.ErrorBaseURL,
+		}).Run()
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"api/repositories"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Executa os comandos de gerenciamento da tabela do DynamoDB.
+func runTable(args []string) error {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "usage: table <create|describe|delete|update-ttl> [flags]")
+	}
+	if len(args) == 0 {
+		usage()
+		return fmt.Errorf("table requires a subcommand")
+	}
+	fs := flag.NewFlagSet("table "+args[0], flag.ContinueOnError)
+	configFlags := newConfigFlags(fs)
+	var enabled, yes bool
+	switch args[0] {
+	case "create", "describe":
+	case "delete":
+		fs.BoolVar(&yes, "yes", false, "confirm the table deletion")
+	case "update-ttl":
+		fs.BoolVar(&enabled, "enabled", true, "enable or disable the TTL on the expiration attribute")
+	default:
+		usage()
+		return fmt.Errorf("unknown table subcommand {%s}", args[0])
+	}
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("table %s does not accept arguments", args[0])
+	}
+	if args[0] == "delete" && !yes {
+		return fmt.Errorf("refusing to delete the table without --yes")
+	}
+	cfg, err := loadApplicationConfig(configFlags)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	client, err := newDynamoDBClient(ctx)
+	if err != nil {
+		return err
+	}
+	repository := repositories.NewDynamoDBRepository(&repositories.DynamoDBConfig{
+		Client: client,
+		Table:  cfg.Table,
+		TTL:    time.Duration(cfg.RecordTTLMinutes) * time.Minute,
+	})
+	switch args[0] {
+	case "create":
+		if err := repository.Create(ctx); err != nil {
+			return err
+		}
+		fmt.Printf("table %s ready\n", cfg.Table)
+	case "describe":
+		description, err := repository.Describe(ctx)
+		if err != nil {
+			return err
+		}
+		printTableDescription(description)
+	case "delete":
+		if err := repository.Drop(ctx); err != nil {
+			return err
+		}
+		fmt.Printf("table %s deleted\n", cfg.Table)
+	case "update-ttl":
+		if err := repository.UpdateTTL(ctx, enabled); err != nil {
+			return err
+		}
+		fmt.Printf("table %s TTL enabled=%t\n", cfg.Table, enabled)
+	}
+	return nil
+}
+
+// Exibe a descrição da tabela.
+func printTableDescription(description *repositories.TableDescription) {
+	fmt.Printf("table:      %s\n", description.Name)
+	fmt.Printf("status:     %s\n", description.Status)
+	fmt.Printf("items:      %d\n", description.ItemCount)
+	fmt.Printf("size:       %d bytes\n", description.SizeBytes)
+	ttl := description.TTLStatus
+	if description.TTLAttribute != "" {
+		ttl = fmt.Sprintf("%s (%s)", ttl, description.TTLAttribute)
+	}
+	fmt.Printf("ttl:        %s\n", ttl)
+	for _, index := range description.Indexes {
+		fmt.Printf("index:      %s %s (%d items)\n", index.Name, index.Status, index.ItemCount)
+	}
+}
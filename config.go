@@ -1,67 +1,630 @@
 package main
 
 import (
+	"api/apis"
 	"api/interfaces"
+	"api/models"
+	"api/repositories"
+	"api/telemetry"
+	"bytes"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// repositório em memória
+	RepositoryMemory = "memory"
+	// repositório no AWS DynamoDB
+	RepositoryDynamoDB = "dynamodb"
+	// telemetria do Lambda exportada em lotes e descarregada ao final de cada invocação
+	LambdaTelemetryFlush = "flush"
+	// telemetria do Lambda exportada para a extensão do coletor (camada do Lambda)
+	LambdaTelemetryExtension = "extension"
+	// prefixo das variáveis de ambiente de configuração
+	configEnvPrefix = "EVENTS_"
 )
 
 // Config representa a configuração da aplicação.
 type Config struct {
 	// arquivo de configuração
-	File string `json:"-"`
+	File string `json:"-" yaml:"-"`
 	// cliente do DynamoDB
-	DynamoDBClient interfaces.DynamoDBClient `json:"-"`
+	DynamoDBClient interfaces.DynamoDBClient `json:"-" yaml:"-"`
 	// repositório de dados
-	Repository interfaces.Repository `json:"-"`
+	Repository interfaces.Repository `json:"-" yaml:"-"`
+	// camada de resiliência do repositório, com o estado do circuit breaker
+	Resilience *repositories.Resilience `json:"-" yaml:"-"`
+	// tipo do repositório de dados (memory ou dynamodb)
+	RepositoryKind string `json:"repository" yaml:"repository"`
+	// nome da tabela do DynamoDB
+	Table string `json:"table" yaml:"table"`
 	// endereço para ativar o servidor
-	Address string `json:"address"`
+	Address string `json:"address" yaml:"address"`
 	// porta do servidor
-	Port int `json:"port"`
+	Port int `json:"port" yaml:"port"`
+	// endereço do listener administrativo
+	AdminAddress string `json:"admin_address" yaml:"admin_address"`
+	// porta do listener administrativo que expõe as métricas do Prometheus em /metrics, zero desativa
+	AdminPort int `json:"admin_port" yaml:"admin_port"`
 	// tempo de expiração dos registros em minutos
-	RecordTTLMinutes int64 `json:"record_ttl_minutes"`
+	RecordTTLMinutes int64 `json:"record_ttl_minutes" yaml:"record_ttl_minutes"`
+	// tempo limite em milissegundos de cada tentativa das operações do repositório, zero desativa
+	RepositoryTimeoutMs int `json:"repository_timeout_ms" yaml:"repository_timeout_ms"`
+	// tempo limite em milissegundos da gravação em lote no repositório, zero desativa
+	RepositoryBatchTimeoutMs int `json:"repository_batch_timeout_ms" yaml:"repository_batch_timeout_ms"`
+	// quantidade total de tentativas das operações do repositório com erros temporários
+	RepositoryRetryAttempts int `json:"repository_retry_attempts" yaml:"repository_retry_attempts"`
+	// intervalo base e máximo em milissegundos entre as tentativas
+	RepositoryRetryBaseDelayMs int `json:"repository_retry_base_delay_ms" yaml:"repository_retry_base_delay_ms"`
+	RepositoryRetryMaxDelayMs  int `json:"repository_retry_max_delay_ms" yaml:"repository_retry_max_delay_ms"`
+	// falhas consecutivas do repositório que abrem o circuito, zero desativa o circuit breaker
+	CircuitFailureThreshold int `json:"circuit_failure_threshold" yaml:"circuit_failure_threshold"`
+	// tempo em segundos que o circuito permanece aberto antes da operação de teste
+	CircuitOpenSeconds int `json:"circuit_open_seconds" yaml:"circuit_open_seconds"`
+	// quantidade máxima de registros no cache de leitura por id, zero desativa
+	CacheSize int `json:"cache_size" yaml:"cache_size"`
+	// tempo em segundos que um registro encontrado permanece no cache
+	CacheTTLSeconds int `json:"cache_ttl_seconds" yaml:"cache_ttl_seconds"`
+	// tempo em segundos que um registro não encontrado permanece no cache, zero desativa
+	CacheNegativeTTLSeconds int `json:"cache_negative_ttl_seconds" yaml:"cache_negative_ttl_seconds"`
+	// tempo limite para escrita da exportação em streaming em segundos
+	ExportWriteTimeoutSeconds int `json:"export_write_timeout_seconds" yaml:"export_write_timeout_seconds"`
+	// rejeita a exportação sem status code, que percorre a tabela inteira com Scan
+	ExportRequireStatusCode bool `json:"export_require_status_code" yaml:"export_require_status_code"`
+	// URL absoluta onde a documentação dos erros (/errors) é servida, como a do API Gateway com o estágio
+	ErrorBaseURL string `json:"error_base_url,omitempty" yaml:"error_base_url,omitempty"`
+	// período em segundos em que a readiness falha antes de recusar novas conexões no encerramento
+	ShutdownDrainSeconds int `json:"shutdown_drain_seconds" yaml:"shutdown_drain_seconds"`
+	// tempo limite em segundos para as requisições em andamento terminarem no encerramento
+	ShutdownTimeoutSeconds int `json:"shutdown_timeout_seconds" yaml:"shutdown_timeout_seconds"`
+	// arquivo do certificado TLS do servidor, vazio usa HTTP sem criptografia
+	TLSCertFile string `json:"tls_cert_file,omitempty" yaml:"tls_cert_file,omitempty"`
+	// arquivo da chave privada do certificado TLS
+	TLSKeyFile string `json:"tls_key_file,omitempty" yaml:"tls_key_file,omitempty"`
+	// arquivo com as autoridades certificadoras dos certificados de cliente (mTLS)
+	TLSClientCAFile string `json:"tls_client_ca_file,omitempty" yaml:"tls_client_ca_file,omitempty"`
+	// verificação do certificado de cliente (none, request, require, verify_if_given ou require_and_verify)
+	TLSClientAuth string `json:"tls_client_auth" yaml:"tls_client_auth"`
+	// versão mínima do TLS (1.2 ou 1.3)
+	TLSMinVersion string `json:"tls_min_version" yaml:"tls_min_version"`
+	// cifras aceitas no TLS 1.2, vazio usa as padrões do Go
+	TLSCipherSuites []string `json:"tls_cipher_suites,omitempty" yaml:"tls_cipher_suites,omitempty"`
+	// exportação da telemetria no AWS Lambda (flush ou extension)
+	LambdaTelemetryExport string `json:"lambda_telemetry_export" yaml:"lambda_telemetry_export"`
+	// nível de log (debug, info, warn ou error)
+	LogLevel string `json:"log_level" yaml:"log_level"`
+	// formato do log na saída padrão (json, text ou none), enviado junto com o exportador de logs
+	LogFormat string `json:"log_format" yaml:"log_format"`
+	// logs de acesso gravados por segundo antes da amostragem, zero grava todos
+	AccessLogSampleFirst int `json:"access_log_sample_first" yaml:"access_log_sample_first"`
+	// após o limite por segundo grava um a cada N logs de acesso, zero descarta os demais
+	AccessLogSampleThereafter int `json:"access_log_sample_thereafter" yaml:"access_log_sample_thereafter"`
+	// intervalo em segundos para verificar alterações no arquivo de configuração, zero desativa
+	ConfigWatchSeconds int `json:"config_watch_seconds" yaml:"config_watch_seconds"`
+	// exportador de cada sinal da telemetria (otlpgrpc, otlphttp, stdout ou none)
+	TelemetryTracesExporter  string `json:"telemetry_traces_exporter" yaml:"telemetry_traces_exporter"`
+	TelemetryMetricsExporter string `json:"telemetry_metrics_exporter" yaml:"telemetry_metrics_exporter"`
+	TelemetryLogsExporter    string `json:"telemetry_logs_exporter" yaml:"telemetry_logs_exporter"`
+	// endereço do coletor OTLP (host:porta ou URL), vazio usa o padrão do SDK
+	TelemetryEndpoint string `json:"telemetry_endpoint,omitempty" yaml:"telemetry_endpoint,omitempty"`
+	// desabilita o TLS na conexão com o coletor, ignorado quando TelemetryCAFile é informado
+	TelemetryInsecure bool `json:"telemetry_insecure" yaml:"telemetry_insecure"`
+	// arquivo com as autoridades certificadoras do coletor, habilita o TLS
+	TelemetryCAFile string `json:"telemetry_ca_file,omitempty" yaml:"telemetry_ca_file,omitempty"`
+	// cabeçalhos enviados ao coletor, como os de autenticação
+	TelemetryHeaders map[string]string `json:"telemetry_headers,omitempty" yaml:"telemetry_headers,omitempty"`
+	// proporção de novos rastreamentos amostrados, entre 0 e 1
+	TelemetrySamplingRatio float64 `json:"telemetry_sampling_ratio" yaml:"telemetry_sampling_ratio"`
+	// detectores de recurso habilitados (host, container, ecs e lambda)
+	TelemetryResourceDetectors []string `json:"telemetry_resource_detectors" yaml:"telemetry_resource_detectors"`
+	// convenções semânticas emitidas (new, old ou dup para as duas durante a transição)
+	TelemetrySemConv string `json:"telemetry_semconv" yaml:"telemetry_semconv"`
+	// regras de mapeamento dos eventos do EventBridge
+	EventBridgeMappings []models.MappingRule `json:"eventbridge_mappings,omitempty" yaml:"eventbridge_mappings,omitempty"`
+	// regras de mapeamento das linhas do CloudWatch Logs
+	LogsMappings []models.MappingRule `json:"logs_mappings,omitempty" yaml:"logs_mappings,omitempty"`
+}
+
+// Define uma configuração que pode ser informada por variável de ambiente ou flag.
+type configSetting struct {
+	// nome da configuração no arquivo, a variável de ambiente é EVENTS_<NOME>
+	// e a flag usa o nome com hífens
+	name string
+	// descrição exibida na ajuda das flags
+	usage string
+	// retorna o ponteiro para o campo da configuração
+	field func(p *Config) interface{}
+}
+
+// configurações que podem ser informadas por variável de ambiente ou flag
+var configSettings = []configSetting{
+	{"repository", "data repository (memory or dynamodb)", func(p *Config) interface{} { return &p.RepositoryKind }},
+	{"table", "DynamoDB table name", func(p *Config) interface{} { return &p.Table }},
+	{"address", "HTTP server address", func(p *Config) interface{} { return &p.Address }},
+	{"port", "HTTP server port", func(p *Config) interface{} { return &p.Port }},
+	{"admin_address", "admin listener address", func(p *Config) interface{} { return &p.AdminAddress }},
+	{"admin_port", "admin listener port serving Prometheus /metrics, 0 disables", func(p *Config) interface{} { return &p.AdminPort }},
+	{"record_ttl_minutes", "record expiration in minutes", func(p *Config) interface{} { return &p.RecordTTLMinutes }},
+	{"repository_timeout_ms", "timeout of each repository attempt in milliseconds, 0 disables", func(p *Config) interface{} { return &p.RepositoryTimeoutMs }},
+	{"repository_batch_timeout_ms", "timeout of repository batch writes in milliseconds, 0 disables", func(p *Config) interface{} { return &p.RepositoryBatchTimeoutMs }},
+	{"repository_retry_attempts", "total attempts of repository operations with transient errors", func(p *Config) interface{} { return &p.RepositoryRetryAttempts }},
+	{"repository_retry_base_delay_ms", "base delay between repository attempts in milliseconds", func(p *Config) interface{} { return &p.RepositoryRetryBaseDelayMs }},
+	{"repository_retry_max_delay_ms", "maximum delay between repository attempts in milliseconds", func(p *Config) interface{} { return &p.RepositoryRetryMaxDelayMs }},
+	{"circuit_failure_threshold", "consecutive repository failures that open the circuit, 0 disables", func(p *Config) interface{} { return &p.CircuitFailureThreshold }},
+	{"circuit_open_seconds", "seconds the circuit stays open before a probe", func(p *Config) interface{} { return &p.CircuitOpenSeconds }},
+	{"cache_size", "maximum events in the read cache, 0 disables", func(p *Config) interface{} { return &p.CacheSize }},
+	{"cache_ttl_seconds", "seconds a found event stays in the read cache", func(p *Config) interface{} { return &p.CacheTTLSeconds }},
+	{"cache_negative_ttl_seconds", "seconds a missing event stays in the read cache, 0 disables", func(p *Config) interface{} { return &p.CacheNegativeTTLSeconds }},
+	{"export_write_timeout_seconds", "streaming export write timeout in seconds", func(p *Config) interface{} { return &p.ExportWriteTimeoutSeconds }},
+	{"export_require_status_code", "reject exports without statusCode, which scan the whole table", func(p *Config) interface{} { return &p.ExportRequireStatusCode }},
+	{"error_base_url", "absolute base URL of the error type documentation", func(p *Config) interface{} { return &p.ErrorBaseURL }},
+	{"shutdown_drain_seconds", "seconds the readiness fails before the listener closes on shutdown", func(p *Config) interface{} { return &p.ShutdownDrainSeconds }},
+	{"shutdown_timeout_seconds", "seconds in-flight requests have to finish on shutdown", func(p *Config) interface{} { return &p.ShutdownTimeoutSeconds }},
+	{"tls_cert_file", "TLS certificate file, enables HTTPS", func(p *Config) interface{} { return &p.TLSCertFile }},
+	{"tls_key_file", "TLS private key file", func(p *Config) interface{} { return &p.TLSKeyFile }},
+	{"tls_client_ca_file", "client CA bundle for mutual TLS", func(p *Config) interface{} { return &p.TLSClientCAFile }},
+	{"tls_client_auth", "client certificate mode (none, request, require, verify_if_given or require_and_verify)", func(p *Config) interface{} { return &p.TLSClientAuth }},
+	{"tls_min_version", "minimum TLS version (1.2 or 1.3)", func(p *Config) interface{} { return &p.TLSMinVersion }},
+	{"tls_cipher_suites", "comma separated TLS 1.2 cipher suites", func(p *Config) interface{} { return &p.TLSCipherSuites }},
+	{"lambda_telemetry_export", "Lambda telemetry export (flush or extension)", func(p *Config) interface{} { return &p.LambdaTelemetryExport }},
+	{"log_level", "log level (debug, info, warn or error)", func(p *Config) interface{} { return &p.LogLevel }},
+	{"log_format", "standard output log format (json, text or none)", func(p *Config) interface{} { return &p.LogFormat }},
+	{"access_log_sample_first", "access logs written per second before sampling, 0 writes all", func(p *Config) interface{} { return &p.AccessLogSampleFirst }},
+	{"access_log_sample_thereafter", "after the per second limit writes one of every N access logs, 0 drops the rest", func(p *Config) interface{} { return &p.AccessLogSampleThereafter }},
+	{"config_watch_seconds", "interval to check the configuration file for changes, 0 disables", func(p *Config) interface{} { return &p.ConfigWatchSeconds }},
+	{"telemetry_traces_exporter", "traces exporter (otlpgrpc, otlphttp, stdout or none)", func(p *Config) interface{} { return &p.TelemetryTracesExporter }},
+	{"telemetry_metrics_exporter", "metrics exporter (otlpgrpc, otlphttp, stdout or none)", func(p *Config) interface{} { return &p.TelemetryMetricsExporter }},
+	{"telemetry_logs_exporter", "logs exporter (otlpgrpc, otlphttp, stdout or none)", func(p *Config) interface{} { return &p.TelemetryLogsExporter }},
+	{"telemetry_endpoint", "OTLP collector endpoint (host:port or URL)", func(p *Config) interface{} { return &p.TelemetryEndpoint }},
+	{"telemetry_insecure", "disable TLS to the OTLP collector", func(p *Config) interface{} { return &p.TelemetryInsecure }},
+	{"telemetry_ca_file", "CA bundle to verify the OTLP collector", func(p *Config) interface{} { return &p.TelemetryCAFile }},
+	{"telemetry_headers", "comma separated key=value headers sent to the OTLP collector", func(p *Config) interface{} { return &p.TelemetryHeaders }},
+	{"telemetry_sampling_ratio", "ratio of new traces sampled, between 0 and 1", func(p *Config) interface{} { return &p.TelemetrySamplingRatio }},
+	{"telemetry_resource_detectors", "comma separated resource detectors (host, container, ecs, lambda)", func(p *Config) interface{} { return &p.TelemetryResourceDetectors }},
+	{"telemetry_semconv", "semantic conventions emitted (new, old or dup)", func(p *Config) interface{} { return &p.TelemetrySemConv }},
+}
+
+// Retorna o nome da variável de ambiente da configuração.
+func (s *configSetting) envName() string {
+	return configEnvPrefix + strings.ToUpper(s.name)
+}
+
+// Retorna o nome da flag da configuração.
+func (s *configSetting) flagName() string {
+	return strings.ReplaceAll(s.name, "_", "-")
+}
+
+// Atribui o valor em texto ao campo da configuração.
+func (s *configSetting) set(p *Config, value string) error {
+	switch field := s.field(p).(type) {
+	case *string:
+		*field = value
+	case *int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*field = n
+	case *int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*field = n
+	case *[]string:
+		*field = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*field = append(*field, item)
+			}
+		}
+	case *bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*field = b
+	case *float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		*field = n
+	case *map[string]string:
+		*field = make(map[string]string)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			key, val, ok := strings.Cut(item, "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				return fmt.Errorf("invalid key=value pair %q", item)
+			}
+			(*field)[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+	default:
+		return fmt.Errorf("unsupported setting type %T", field)
+	}
+	return nil
 }
 
 // Cria uma instância da configuração da aplicação com valores padrão.
 func NewConfig(file string) *Config {
 	return &Config{
-		File:             file,
-		Address:          "0.0.0.0",
-		Port:             7000,
-		RecordTTLMinutes: 24 * 60,
+		File:                       file,
+		RepositoryKind:             RepositoryMemory,
+		Table:                      "eventos",
+		Address:                    "0.0.0.0",
+		Port:                       7000,
+		AdminAddress:               "0.0.0.0",
+		RecordTTLMinutes:           24 * 60,
+		RepositoryTimeoutMs:        2000,
+		RepositoryBatchTimeoutMs:   10000,
+		RepositoryRetryAttempts:    3,
+		RepositoryRetryBaseDelayMs: 50,
+		RepositoryRetryMaxDelayMs:  1000,
+		CircuitFailureThreshold:    5,
+		CircuitOpenSeconds:         30,
+		CacheTTLSeconds:            60,
+		CacheNegativeTTLSeconds:    5,
+		ExportWriteTimeoutSeconds:  10 * 60,
+		ShutdownDrainSeconds:       5,
+		ShutdownTimeoutSeconds:     30,
+		TLSClientAuth:              "none",
+		TLSMinVersion:              "1.2",
+		LambdaTelemetryExport:      LambdaTelemetryFlush,
+		LogLevel:                   "info",
+		LogFormat:                  LogFormatJSON,
+		AccessLogSampleFirst:       100,
+		AccessLogSampleThereafter:  10,
+		TelemetryTracesExporter:    ExporterOTLPGRPC,
+		TelemetryMetricsExporter:   ExporterOTLPGRPC,
+		TelemetryLogsExporter:      ExporterOTLPGRPC,
+		TelemetryInsecure:          true,
+		TelemetrySamplingRatio:     1,
+		TelemetryResourceDetectors: append([]string(nil), resourceDetectors...),
+		TelemetrySemConv:           telemetry.SemConvNew,
 	}
 }
 
-// Salva as configurações em um arquivo.
-func (p *Config) Save() error {
-	f, err := os.OpenFile(p.File, os.O_CREATE|os.O_WRONLY, 0750)
+// Valida as configurações, retornando todos os problemas encontrados.
+func (p *Config) Validate() error {
+	var errs []error
+	switch p.RepositoryKind {
+	case RepositoryMemory:
+	case RepositoryDynamoDB:
+		if strings.TrimSpace(p.Table) == "" {
+			errs = append(errs, fmt.Errorf("table: must not be empty when repository is %s", RepositoryDynamoDB))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("repository: unknown kind %q, expected %s or %s", p.RepositoryKind, RepositoryMemory, RepositoryDynamoDB))
+	}
+	if p.Port < 1 || p.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port: must be between 1 and 65535, got %d", p.Port))
+	}
+	if p.AdminPort < 0 || p.AdminPort > 65535 {
+		errs = append(errs, fmt.Errorf("admin_port: must be between 0 and 65535, got %d", p.AdminPort))
+	} else if p.AdminPort != 0 && p.AdminPort == p.Port && p.AdminAddress == p.Address {
+		errs = append(errs, fmt.Errorf("admin_port: must differ from port %d", p.Port))
+	}
+	if p.RecordTTLMinutes <= 0 {
+		errs = append(errs, fmt.Errorf("record_ttl_minutes: must be greater than zero, got %d", p.RecordTTLMinutes))
+	}
+	if p.RepositoryTimeoutMs < 0 {
+		errs = append(errs, fmt.Errorf("repository_timeout_ms: must not be negative, got %d", p.RepositoryTimeoutMs))
+	}
+	if p.RepositoryBatchTimeoutMs < 0 {
+		errs = append(errs, fmt.Errorf("repository_batch_timeout_ms: must not be negative, got %d", p.RepositoryBatchTimeoutMs))
+	}
+	if p.RepositoryRetryAttempts < 1 {
+		errs = append(errs, fmt.Errorf("repository_retry_attempts: must be at least 1, got %d", p.RepositoryRetryAttempts))
+	}
+	if p.RepositoryRetryBaseDelayMs < 0 || p.RepositoryRetryMaxDelayMs < p.RepositoryRetryBaseDelayMs {
+		errs = append(errs, fmt.Errorf("repository_retry_max_delay_ms: must not be less than repository_retry_base_delay_ms, got %d and %d", p.RepositoryRetryMaxDelayMs, p.RepositoryRetryBaseDelayMs))
+	}
+	if p.CircuitFailureThreshold < 0 {
+		errs = append(errs, fmt.Errorf("circuit_failure_threshold: must not be negative, got %d", p.CircuitFailureThreshold))
+	}
+	if p.CircuitFailureThreshold > 0 && p.CircuitOpenSeconds <= 0 {
+		errs = append(errs, fmt.Errorf("circuit_open_seconds: must be greater than zero when the circuit breaker is enabled, got %d", p.CircuitOpenSeconds))
+	}
+	if p.CacheSize < 0 {
+		errs = append(errs, fmt.Errorf("cache_size: must not be negative, got %d", p.CacheSize))
+	}
+	if p.CacheSize > 0 && p.CacheTTLSeconds <= 0 {
+		errs = append(errs, fmt.Errorf("cache_ttl_seconds: must be greater than zero when the cache is enabled, got %d", p.CacheTTLSeconds))
+	}
+	if p.CacheNegativeTTLSeconds < 0 {
+		errs = append(errs, fmt.Errorf("cache_negative_ttl_seconds: must not be negative, got %d", p.CacheNegativeTTLSeconds))
+	}
+	if p.ExportWriteTimeoutSeconds <= 0 {
+		errs = append(errs, fmt.Errorf("export_write_timeout_seconds: must be greater than zero, got %d", p.ExportWriteTimeoutSeconds))
+	}
+	if p.ErrorBaseURL != "" {
+		if u, err := url.Parse(p.ErrorBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("error_base_url: must be an absolute http or https URL, got %q", p.ErrorBaseURL))
+		}
+	}
+	if p.ShutdownDrainSeconds < 0 {
+		errs = append(errs, fmt.Errorf("shutdown_drain_seconds: must not be negative, got %d", p.ShutdownDrainSeconds))
+	}
+	if p.ShutdownTimeoutSeconds <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown_timeout_seconds: must be greater than zero, got %d", p.ShutdownTimeoutSeconds))
+	}
+	if _, err := p.TLSConfig(); err != nil {
+		errs = append(errs, err)
+	}
+	if p.LambdaTelemetryExport != LambdaTelemetryFlush && p.LambdaTelemetryExport != LambdaTelemetryExtension {
+		errs = append(errs, fmt.Errorf("lambda_telemetry_export: unknown mode %q, expected %s or %s", p.LambdaTelemetryExport, LambdaTelemetryFlush, LambdaTelemetryExtension))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(p.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("log_level: unknown level %q, expected debug, info, warn or error", p.LogLevel))
+	}
+	switch p.LogFormat {
+	case LogFormatJSON, LogFormatText, LogFormatNone:
+	default:
+		errs = append(errs, fmt.Errorf("log_format: unknown format %q, expected %s, %s or %s", p.LogFormat, LogFormatJSON, LogFormatText, LogFormatNone))
+	}
+	if p.AccessLogSampleFirst < 0 {
+		errs = append(errs, fmt.Errorf("access_log_sample_first: must not be negative, got %d", p.AccessLogSampleFirst))
+	}
+	if p.AccessLogSampleThereafter < 0 {
+		errs = append(errs, fmt.Errorf("access_log_sample_thereafter: must not be negative, got %d", p.AccessLogSampleThereafter))
+	}
+	if p.ConfigWatchSeconds < 0 {
+		errs = append(errs, fmt.Errorf("config_watch_seconds: must not be negative, got %d", p.ConfigWatchSeconds))
+	}
+	for _, setting := range []struct{ name, exporter string }{
+		{"telemetry_traces_exporter", p.TelemetryTracesExporter},
+		{"telemetry_metrics_exporter", p.TelemetryMetricsExporter},
+		{"telemetry_logs_exporter", p.TelemetryLogsExporter},
+	} {
+		switch setting.exporter {
+		case ExporterOTLPGRPC, ExporterOTLPHTTP, ExporterStdout, ExporterNone:
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown exporter %q, expected %s, %s, %s or %s", setting.name, setting.exporter, ExporterOTLPGRPC, ExporterOTLPHTTP, ExporterStdout, ExporterNone))
+		}
+	}
+	if p.TelemetryCAFile != "" {
+		if _, err := os.Stat(p.TelemetryCAFile); err != nil {
+			errs = append(errs, fmt.Errorf("telemetry_ca_file: %w", err))
+		}
+	}
+	if p.TelemetrySamplingRatio < 0 || p.TelemetrySamplingRatio > 1 {
+		errs = append(errs, fmt.Errorf("telemetry_sampling_ratio: must be between 0 and 1, got %g", p.TelemetrySamplingRatio))
+	}
+	for _, detector := range p.TelemetryResourceDetectors {
+		if !slices.Contains(resourceDetectors, detector) {
+			errs = append(errs, fmt.Errorf("telemetry_resource_detectors: unknown detector %q, expected %s", detector, strings.Join(resourceDetectors, ", ")))
+		}
+	}
+	switch p.TelemetrySemConv {
+	case telemetry.SemConvNew, telemetry.SemConvOld, telemetry.SemConvDup:
+	default:
+		errs = append(errs, fmt.Errorf("telemetry_semconv: unknown conventions %q, expected %s, %s or %s", p.TelemetrySemConv, telemetry.SemConvNew, telemetry.SemConvOld, telemetry.SemConvDup))
+	}
+	for i, rule := range p.EventBridgeMappings {
+		if err := rule.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("eventbridge_mappings[%d]: %w", i, err))
+		}
+	}
+	for i, rule := range p.LogsMappings {
+		if err := rule.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("logs_mappings[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Retorna a configuração de TLS do servidor ou nil se o TLS não estiver habilitado.
+func (p *Config) TLSConfig() (*apis.TLSConfig, error) {
+	var errs []error
+	if (p.TLSCertFile == "") != (p.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("tls_cert_file, tls_key_file: both must be informed to enable TLS"))
+	}
+	clientAuth, err := apis.ParseClientAuth(p.TLSClientAuth)
 	if err != nil {
-		return err
+		errs = append(errs, fmt.Errorf("tls_client_auth: %w", err))
 	}
-	defer f.Close()
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", " ")
-	err = enc.Encode(p)
+	minVersion, err := apis.ParseTLSVersion(p.TLSMinVersion)
 	if err != nil {
-		return err
+		errs = append(errs, fmt.Errorf("tls_min_version: %w", err))
 	}
-	return nil
+	cipherSuites, err := apis.ParseCipherSuites(p.TLSCipherSuites)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("tls_cipher_suites: %w", err))
+	}
+	if p.TLSCertFile == "" && (p.TLSClientCAFile != "" || clientAuth != tls.NoClientCert) {
+		errs = append(errs, fmt.Errorf("tls_client_ca_file, tls_client_auth: require tls_cert_file and tls_key_file"))
+	}
+	if p.TLSClientCAFile == "" && (clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert) {
+		errs = append(errs, fmt.Errorf("tls_client_auth: mode %s requires tls_client_ca_file", p.TLSClientAuth))
+	}
+	if len(errs) > 0 || p.TLSCertFile == "" {
+		return nil, errors.Join(errs...)
+	}
+	return &apis.TLSConfig{
+		CertFile:     p.TLSCertFile,
+		KeyFile:      p.TLSKeyFile,
+		ClientCAFile: p.TLSClientCAFile,
+		ClientAuth:   clientAuth,
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+	}, nil
 }
 
-// Carrega as configurações de um arquivo ou retorna a configuração padrão se o arquivo não existir.
-func LoadConfig(file string) (config *Config, err error) {
-	f, err := os.OpenFile(file, os.O_RDONLY, 0750)
-	if err != nil {
-		if os.IsNotExist(err) {
-			cfg := NewConfig(file)
-			return cfg, cfg.Save()
-		}
-		return nil, err
+// Retorna as opções da telemetria configuradas.
+// A exportação síncrona é definida pelo modo de execução.
+func (p *Config) TelemetryOptions() telemetryOptions {
+	return telemetryOptions{
+		tracesExporter:    p.TelemetryTracesExporter,
+		metricsExporter:   p.TelemetryMetricsExporter,
+		logsExporter:      p.TelemetryLogsExporter,
+		endpoint:          p.TelemetryEndpoint,
+		insecure:          p.TelemetryInsecure,
+		caFile:            p.TelemetryCAFile,
+		headers:           p.TelemetryHeaders,
+		samplingRatio:     p.TelemetrySamplingRatio,
+		resourceDetectors: p.TelemetryResourceDetectors,
+		semConv:           p.TelemetrySemConv,
+	}
+}
+
+// Retorna o nível de log configurado, ou info se ele for inválido.
+func (p *Config) Level() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(p.LogLevel)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// Retorna as configurações alteradas em relação à configuração informada
+// que só têm efeito após reiniciar a aplicação.
+func (p *Config) RestartRequiredChanges(other *Config) []string {
+	changes := make([]string, 0)
+	if p.RepositoryKind != other.RepositoryKind {
+		changes = append(changes, "repository")
+	}
+	if p.Table != other.Table {
+		changes = append(changes, "table")
+	}
+	if p.RepositoryTimeoutMs != other.RepositoryTimeoutMs || p.RepositoryBatchTimeoutMs != other.RepositoryBatchTimeoutMs ||
+		p.RepositoryRetryAttempts != other.RepositoryRetryAttempts || p.RepositoryRetryBaseDelayMs != other.RepositoryRetryBaseDelayMs ||
+		p.RepositoryRetryMaxDelayMs != other.RepositoryRetryMaxDelayMs || p.CircuitFailureThreshold != other.CircuitFailureThreshold ||
+		p.CircuitOpenSeconds != other.CircuitOpenSeconds {
+		changes = append(changes, "resilience")
+	}
+	if p.CacheSize != other.CacheSize || p.CacheTTLSeconds != other.CacheTTLSeconds || p.CacheNegativeTTLSeconds != other.CacheNegativeTTLSeconds {
+		changes = append(changes, "cache")
+	}
+	if p.ExportRequireStatusCode != other.ExportRequireStatusCode {
+		changes = append(changes, "export_require_status_code")
+	}
+	if p.ErrorBaseURL != other.ErrorBaseURL {
+		changes = append(changes, "error_base_url")
 	}
-	defer f.Close()
-	err = json.NewDecoder(f).Decode(&config)
+	if p.Address != other.Address {
+		changes = append(changes, "address")
+	}
+	if p.Port != other.Port {
+		changes = append(changes, "port")
+	}
+	if p.AdminAddress != other.AdminAddress || p.AdminPort != other.AdminPort {
+		changes = append(changes, "admin")
+	}
+	if p.TLSCertFile != other.TLSCertFile || p.TLSKeyFile != other.TLSKeyFile || p.TLSClientCAFile != other.TLSClientCAFile ||
+		p.TLSClientAuth != other.TLSClientAuth || p.TLSMinVersion != other.TLSMinVersion ||
+		!reflect.DeepEqual(p.TLSCipherSuites, other.TLSCipherSuites) {
+		changes = append(changes, "tls")
+	}
+	if p.ConfigWatchSeconds != other.ConfigWatchSeconds {
+		changes = append(changes, "config_watch_seconds")
+	}
+	if p.LogFormat != other.LogFormat {
+		changes = append(changes, "log_format")
+	}
+	if !reflect.DeepEqual(p.TelemetryOptions(), other.TelemetryOptions()) {
+		changes = append(changes, "telemetry")
+	}
+	if !reflect.DeepEqual(p.EventBridgeMappings, other.EventBridgeMappings) {
+		changes = append(changes, "eventbridge_mappings")
+	}
+	if !reflect.DeepEqual(p.LogsMappings, other.LogsMappings) {
+		changes = append(changes, "logs_mappings")
+	}
+	return changes
+}
+
+// Carrega as configurações de um arquivo JSON ou YAML (pela extensão .yaml ou .yml).
+// Os campos ausentes no arquivo mantêm os valores padrão e campos desconhecidos são rejeitados.
+// O arquivo nunca é criado ou alterado.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
 	if err != nil {
 		return nil, err
 	}
+	config := NewConfig(file)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("unable to parse %s, %w", file, err)
+		}
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(config); err != nil {
+			return nil, fmt.Errorf("unable to parse %s, %w", file, err)
+		}
+	}
 	return config, nil
 }
+
+// Aplica as variáveis de ambiente EVENTS_* definidas sobre a configuração.
+func (p *Config) LoadEnv(lookup func(key string) (string, bool)) error {
+	var errs []error
+	for _, setting := range configSettings {
+		value, ok := lookup(setting.envName())
+		if !ok {
+			continue
+		}
+		if err := setting.set(p, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", setting.envName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flags de configuração comuns aos comandos.
+type configFlags struct {
+	// arquivo de configuração informado em --config
+	file string
+	// valores informados nas flags, indexados pelo nome da configuração
+	values map[string]*string
+	// flags informadas na linha de comando
+	fs *flag.FlagSet
+}
+
+// Registra as flags de configuração no conjunto de flags do comando.
+func newConfigFlags(fs *flag.FlagSet) *configFlags {
+	c := &configFlags{values: make(map[string]*string), fs: fs}
+	fs.StringVar(&c.file, "config", "", "configuration file in JSON or YAML (env EVENTS_CONFIG)")
+	for _, setting := range configSettings {
+		c.values[setting.name] = fs.String(setting.flagName(), "", fmt.Sprintf("%s (env %s)", setting.usage, setting.envName()))
+	}
+	return c
+}
+
+// Aplica as flags informadas na linha de comando sobre a configuração.
+func (c *configFlags) apply(p *Config) error {
+	var errs []error
+	for _, setting := range configSettings {
+		setting := setting
+		c.fs.Visit(func(f *flag.Flag) {
+			if f.Name != setting.flagName() {
+				return
+			}
+			if err := setting.set(p, *c.values[setting.name]); err != nil {
+				errs = append(errs, fmt.Errorf("--%s: %w", f.Name, err))
+			}
+		})
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+)
+
+// Chave da identidade do chamador no contexto da requisição.
+type callerIdentityKey struct{}
+
+// Retorna o contexto com a identidade do chamador.
+func WithCallerIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, callerIdentityKey{}, identity)
+}
+
+// Retorna a identidade do chamador ou vazio se ela não for conhecida.
+func CallerIdentity(ctx context.Context) string {
+	identity, _ := ctx.Value(callerIdentityKey{}).(string)
+	return identity
+}
+
+// Retorna a identidade do chamador a partir do subject do certificado de cliente.
+// Apenas certificados com a cadeia verificada são considerados, pois nos modos
+// sem verificação o subject apresentado pelo cliente não é confiável.
+func callerIdentityFromTLS(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.String()
+}
+
+// Middleware que adiciona ao contexto a identidade do chamador obtida do certificado de cliente.
+func CallerIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if identity := callerIdentityFromTLS(r); identity != "" {
+			r = r.WithContext(WithCallerIdentity(r.Context(), identity))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
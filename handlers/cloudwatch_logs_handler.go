@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"api/interfaces"
+	"api/models"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Configuração do CloudWatchLogsHandler.
+type CloudWatchLogsHandlerConfig struct {
+	// repositório de dados
+	Repository interfaces.Repository
+	// regras de mapeamento das linhas de log
+	Rules []models.MappingRule
+}
+
+// Estrutura do CloudWatchLogsHandler.
+type CloudWatchLogsHandler struct {
+	// configuração do handler
+	config *CloudWatchLogsHandlerConfig
+	// mapeador de eventos
+	mapper *eventMapper
+	// configura o tracer
+	tracer trace.Tracer
+	// metricas de linhas de log
+	logCounter metric.Int64Counter
+}
+
+// Cria uma nova instância do CloudWatchLogsHandler.
+func NewCloudWatchLogsHandler(config *CloudWatchLogsHandlerConfig) *CloudWatchLogsHandler {
+	h := &CloudWatchLogsHandler{
+		config: config,
+		mapper: newEventMapper(config.Rules),
+		tracer: otel.Tracer("cloudwatch.logs.handler"),
+	}
+	// configura as metricas
+	meter := otel.Meter("cloudwatch.logs.consumer.metrics")
+	if counter, err := meter.Int64Counter("custom.cloudwatch.logs.events.total",
+		metric.WithDescription("The number of CloudWatch Logs events processed"),
+		metric.WithUnit("{events}")); err == nil {
+		h.logCounter = counter
+	} else {
+		panic(err)
+	}
+	return h
+}
+
+// Processa os dados de um filtro de assinatura do CloudWatch Logs, convertendo
+// cada linha pelas regras de mapeamento e salvando os eventos no repositório.
+// Linhas sem regra compatível são ignoradas.
+func (p *CloudWatchLogsHandler) HandleRequest(ctx context.Context, logsEvent events.CloudwatchLogsEvent) error {
+	ctx, span := p.tracer.Start(ctx, "HandleRequest",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(lambdaInvocationAttributes(ctx)...),
+		trace.WithAttributes(attribute.String("faas.trigger", "other")),
+	)
+	defer span.End()
+	start := time.Now()
+	data, err := logsEvent.AWSLogs.Parse()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to decode logs data")
+		slog.ErrorContext(ctx, "unable to decode logs data", "error", err)
+		return err
+	}
+	span.SetAttributes(
+		attribute.String("aws.log.group.names", data.LogGroup),
+		attribute.String("aws.log.stream.names", data.LogStream),
+		attribute.Int("messaging.batch.message_count", len(data.LogEvents)),
+	)
+	// mensagens de controle não contêm linhas de log
+	if data.MessageType == "CONTROL_MESSAGE" {
+		span.AddEvent("control message ignored")
+		return nil
+	}
+	results := make(map[string]int64)
+	batch := make([]*models.Event, 0, len(data.LogEvents))
+	for _, logEvent := range data.LogEvents {
+		var document interface{}
+		if message := strings.TrimSpace(logEvent.Message); strings.HasPrefix(message, "{") {
+			if err := json.Unmarshal([]byte(message), &document); err != nil {
+				document = nil
+			}
+		}
+		event, _, err := p.mapper.mapInput(&mappingInput{
+			source:   data.LogGroup,
+			text:     logEvent.Message,
+			document: document,
+			date:     time.UnixMilli(logEvent.Timestamp).UTC(),
+			message:  logEvent.Message,
+		})
+		if err == nil && event != nil {
+			err = event.Validate()
+		}
+		if err != nil {
+			results["invalid"]++
+			span.AddEvent(
+				"record mapping failed",
+				trace.WithAttributes(attribute.String("error", err.Error())),
+			)
+			slog.ErrorContext(ctx, "unable to map log event", "log_event_id", logEvent.ID, "error", err)
+			continue
+		}
+		if event == nil {
+			results["unmatched"]++
+			continue
+		}
+		// o id é derivado do id da linha de log para que novas tentativas não dupliquem registros
+		event.Id = uuid.NewSHA1(uuid.NameSpaceOID, []byte(logEvent.ID)).String()
+		batch = append(batch, event)
+	}
+	defer func() {
+		for result, count := range results {
+			p.logCounter.Add(ctx, count, metric.WithAttributes(
+				attribute.String("aws.log.group.names", data.LogGroup),
+				attribute.String("result", result),
+			))
+		}
+	}()
+	if len(batch) > 0 {
+		failed, err := p.config.Repository.SaveBatch(ctx, batch)
+		results["success"] += int64(len(batch) - len(failed))
+		if err != nil {
+			results["failure"] += int64(len(failed))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "unable to save records in repository")
+			slog.ErrorContext(ctx, "unable to save records in repository", "records", len(failed), "error", err)
+			return err
+		}
+	}
+	slog.InfoContext(
+		ctx,
+		"logs processed",
+		"duration_ms", time.Since(start).Milliseconds(),
+		"log_group", data.LogGroup,
+		"lines", len(data.LogEvents),
+		"saved", results["success"],
+		"unmatched", results["unmatched"],
+		"invalid", results["invalid"],
+	)
+	return nil
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"api/models"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Código estável dos erros da API, retornado no campo code das respostas.
+// Os códigos não mudam entre versões e podem ser usados pelos clientes no lugar das mensagens.
+type ErrorCode string
+
+const (
+	ErrorMissingEventID          ErrorCode = "MISSING_EVENT_ID"
+	ErrorInvalidRequest          ErrorCode = "INVALID_REQUEST"
+	ErrorInvalidJSON             ErrorCode = "INVALID_JSON"
+	ErrorInvalidEvent            ErrorCode = "INVALID_EVENT"
+	ErrorInvalidQuery            ErrorCode = "INVALID_QUERY"
+	ErrorInvalidParameter        ErrorCode = "INVALID_PARAMETER"
+	ErrorInvalidDateRange        ErrorCode = "INVALID_DATE_RANGE"
+	ErrorUnsupportedExportFormat ErrorCode = "UNSUPPORTED_EXPORT_FORMAT"
+	ErrorStatusCodeRequired      ErrorCode = "STATUS_CODE_REQUIRED"
+	ErrorEventNotFound           ErrorCode = "EVENT_NOT_FOUND"
+	ErrorEventConflict           ErrorCode = "EVENT_CONFLICT"
+	ErrorEventRejected           ErrorCode = "EVENT_REJECTED"
+	ErrorMethodNotAllowed        ErrorCode = "METHOD_NOT_ALLOWED"
+	ErrorErrorTypeNotFound       ErrorCode = "ERROR_TYPE_NOT_FOUND"
+	ErrorRepositoryThrottled     ErrorCode = "REPOSITORY_THROTTLED"
+	ErrorRepositoryUnavailable   ErrorCode = "REPOSITORY_UNAVAILABLE"
+	ErrorRepositoryCircuitOpen   ErrorCode = "REPOSITORY_CIRCUIT_OPEN"
+	ErrorInternal                ErrorCode = "INTERNAL_ERROR"
+)
+
+const (
+	// idioma padrão das mensagens de erro
+	LanguageEnglish = "en"
+	// português do Brasil
+	LanguagePortuguese = "pt-BR"
+	// caminho onde os tipos dos erros são servidos pela API
+	errorTypePath = "/errors/"
+)
+
+// Mensagens de um erro em um idioma. O detalhe pode ter verbos do fmt preenchidos na resposta.
+type errorMessages struct {
+	title       string
+	detail      string
+	description string
+}
+
+// Definição de um erro do catálogo.
+type errorDefinition struct {
+	status   int
+	messages map[string]errorMessages
+}
+
+// Catálogo dos erros da API com as mensagens em cada idioma.
+var errorCatalog = map[ErrorCode]errorDefinition{
+	ErrorMissingEventID: {http.StatusBadRequest, map[string]errorMessages{
+		LanguageEnglish:    {"Missing event ID", "Missing event ID in URL", "The URL must end with the ID of the event."},
+		LanguagePortuguese: {"ID do evento ausente", "O ID do evento não foi informado na URL", "A URL deve terminar com o ID do evento."},
+	}},
+	ErrorInvalidRequest: {http.StatusBadRequest, map[string]errorMessages{
+		LanguageEnglish:    {"Invalid request", "The request could not be interpreted: %s", "The Lambda event must be an HTTP request from API Gateway, ALB or a function URL."},
+		LanguagePortuguese: {"Requisição inválida", "A requisição não pôde ser interpretada: %s", "O evento do Lambda deve ser uma requisição HTTP do API Gateway, do ALB ou de uma URL de função."},
+	}},
+	ErrorInvalidJSON: {http.StatusBadRequest, map[string]errorMessages{
+		LanguageEnglish:    {"Invalid JSON", "The request body is not valid JSON: %s", "The request body must be a JSON object as defined in RFC 8259."},
+		LanguagePortuguese: {"JSON inválido", "O corpo da requisição não é um JSON válido: %s", "O corpo da requisição deve ser um objeto JSON conforme a RFC 8259."},
+	}},
+	ErrorInvalidEvent: {http.StatusBadRequest, map[string]errorMessages{
+		LanguageEnglish:    {"Invalid event", "The event is invalid: %s", "The event must have a date and a status code greater than or equal to zero."},
+		LanguagePortuguese: {"Evento inválido", "O evento é inválido: %s", "O evento deve ter data e status code maior ou igual a zero."},
+	}},
+	ErrorInvalidQuery: {http.StatusBadRequest, map[string]errorMessages{
+		LanguageEnglish:    {"Invalid query", "The query string is invalid: %s", "The query string of the URL could not be parsed."},
+		LanguagePortuguese: {"Consulta inválida", "Os parâmetros da URL são inválidos: %s", "Os parâmetros da URL não puderam ser interpretados."},
+	}},
+	ErrorInvalidParameter: {http.StatusBadRequest, map[string]errorMessages{
+		LanguageEnglish:    {"Invalid parameter", "Parameter {%s} is invalid: %s", "The dates from and to must use RFC 3339 and the statusCode must be an integer."},
+		LanguagePortuguese: {"Parâmetro inválido", "O parâmetro {%s} é inválido: %s", "As datas from e to devem usar a RFC 3339 e o statusCode deve ser um número inteiro."},
+	}},
+	ErrorInvalidDateRange: {http.StatusBadRequest, map[string]errorMessages{
+		LanguageEnglish:    {"Invalid date range", "Parameter {from} must not be after {to}", "The start of the period must not be after its end."},
+		LanguagePortuguese: {"Período inválido", "O parâmetro {from} não pode ser posterior a {to}", "O início do período não pode ser posterior ao fim."},
+	}},
+	ErrorUnsupportedExportFormat: {http.StatusBadRequest, map[string]errorMessages{
+		LanguageEnglish:    {"Unsupported export format", "Parameter {format} is invalid, supported formats are ndjson and csv", "The export supports the ndjson and csv formats."},
+		LanguagePortuguese: {"Formato de exportação não suportado", "O parâmetro {format} é inválido, os formatos suportados são ndjson e csv", "A exportação suporta os formatos ndjson e csv."},
+	}},
+	ErrorStatusCodeRequired: {http.StatusBadRequest, map[string]errorMessages{
+		LanguageEnglish:    {"Status code required", "Parameter {statusCode} is required to export events", "The export without a status code reads the whole table and is disabled on this server."},
+		LanguagePortuguese: {"Status code obrigatório", "O parâmetro {statusCode} é obrigatório para exportar eventos", "A exportação sem status code percorre a tabela inteira e está desabilitada neste servidor."},
+	}},
+	ErrorEventNotFound: {http.StatusNotFound, map[string]errorMessages{
+		LanguageEnglish:    {"Event not found", "Event not found", "There is no event with the ID informed or it has expired."},
+		LanguagePortuguese: {"Evento não encontrado", "Evento não encontrado", "Não há evento com o ID informado ou ele expirou."},
+	}},
+	ErrorEventConflict: {http.StatusConflict, map[string]errorMessages{
+		LanguageEnglish:    {"Event conflict", "Event was changed by another request", "The event was changed by another request, read it again before retrying."},
+		LanguagePortuguese: {"Conflito no evento", "O evento foi alterado por outra requisição", "O evento foi alterado por outra requisição, consulte-o novamente antes de repetir."},
+	}},
+	ErrorEventRejected: {http.StatusBadRequest, map[string]errorMessages{
+		LanguageEnglish:    {"Event rejected", "Event was rejected by the repository", "The repository did not accept the event, usually because of its size or attribute values."},
+		LanguagePortuguese: {"Evento rejeitado", "O evento foi rejeitado pelo repositório", "O repositório não aceitou o evento, normalmente pelo tamanho ou pelos valores dos atributos."},
+	}},
+	ErrorMethodNotAllowed: {http.StatusMethodNotAllowed, map[string]errorMessages{
+		LanguageEnglish:    {"Method not allowed", "Method %s is not allowed", "The events accept the GET, POST, PUT and DELETE methods."},
+		LanguagePortuguese: {"Método não permitido", "O método %s não é permitido", "Os eventos aceitam os métodos GET, POST, PUT e DELETE."},
+	}},
+	ErrorErrorTypeNotFound: {http.StatusNotFound, map[string]errorMessages{
+		LanguageEnglish:    {"Error type not found", "There is no error with code %s", "The error code is not part of the catalog."},
+		LanguagePortuguese: {"Tipo de erro não encontrado", "Não há erro com o código %s", "O código de erro não faz parte do catálogo."},
+	}},
+	ErrorRepositoryThrottled: {http.StatusServiceUnavailable, map[string]errorMessages{
+		LanguageEnglish:    {"Too many requests", "Too many requests to the repository, try again later", "The repository limited the request rate, retry after the time in the Retry-After header."},
+		LanguagePortuguese: {"Requisições em excesso", "Há requisições em excesso no repositório, tente novamente mais tarde", "O repositório limitou a vazão das requisições, repita após o tempo do cabeçalho Retry-After."},
+	}},
+	ErrorRepositoryUnavailable: {http.StatusServiceUnavailable, map[string]errorMessages{
+		LanguageEnglish:    {"Repository unavailable", "Repository is temporarily unavailable, try again later", "The repository is unavailable or did not respond in time."},
+		LanguagePortuguese: {"Repositório indisponível", "O repositório está temporariamente indisponível, tente novamente mais tarde", "O repositório está indisponível ou não respondeu a tempo."},
+	}},
+	ErrorRepositoryCircuitOpen: {http.StatusServiceUnavailable, map[string]errorMessages{
+		LanguageEnglish:    {"Repository circuit open", "Repository calls are suspended after repeated failures, try again later", "The repository failed repeatedly and the calls are suspended, retry after the time in the Retry-After header."},
+		LanguagePortuguese: {"Circuito do repositório aberto", "As chamadas ao repositório estão suspensas após falhas seguidas, tente novamente mais tarde", "O repositório falhou seguidamente e as chamadas estão suspensas, repita após o tempo do cabeçalho Retry-After."},
+	}},
+	ErrorInternal: {http.StatusInternalServerError, map[string]errorMessages{
+		LanguageEnglish:    {"Internal error", "Unable to process the request", "An unexpected error occurred, the details are in the application logs."},
+		LanguagePortuguese: {"Erro interno", "Não foi possível processar a requisição", "Ocorreu um erro inesperado, os detalhes estão nos logs da aplicação."},
+	}},
+}
+
+// Retorna as mensagens do erro no idioma informado, ou em inglês se não houver tradução.
+func (d errorDefinition) messagesFor(language string) errorMessages {
+	if messages, ok := d.messages[language]; ok {
+		return messages
+	}
+	return d.messages[LanguageEnglish]
+}
+
+// Retorna o endereço da documentação do erro a partir da base informada: a URL absoluta
+// configurada ou o prefixo do caminho pelo qual a API é acessada, vazio na raiz.
+func errorTypeURI(base string, code ErrorCode) string {
+	return strings.TrimSuffix(base, "/") + errorTypePath + string(code)
+}
+
+// Cria a resposta do erro do catálogo no idioma informado, preenchendo o detalhe com args.
+func newProblem(code ErrorCode, language string, base string, instance string, args ...any) models.ErrorResponse {
+	definition, ok := errorCatalog[code]
+	if !ok {
+		code, definition = ErrorInternal, errorCatalog[ErrorInternal]
+	}
+	messages := definition.messagesFor(language)
+	detail := messages.detail
+	if len(args) > 0 {
+		detail = fmt.Sprintf(detail, args...)
+	}
+	return models.ErrorResponse{
+		Type:     errorTypeURI(base, code),
+		Title:    messages.title,
+		Status:   definition.status,
+		Detail:   detail,
+		Instance: instance,
+		Code:     string(code),
+	}
+}
+
+// Retorna a documentação do tipo do erro no idioma informado.
+func errorType(code ErrorCode, language string, base string) (models.ErrorType, bool) {
+	definition, ok := errorCatalog[code]
+	if !ok {
+		return models.ErrorType{}, false
+	}
+	messages := definition.messagesFor(language)
+	return models.ErrorType{
+		Type:        errorTypeURI(base, code),
+		Code:        string(code),
+		Status:      definition.status,
+		Title:       messages.title,
+		Description: messages.description,
+	}, true
+}
+
+// Retorna a documentação de todos os erros do catálogo, ordenados pelo código.
+func errorTypes(language string, base string) []models.ErrorType {
+	codes := make([]ErrorCode, 0, len(errorCatalog))
+	for code := range errorCatalog {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	types := make([]models.ErrorType, 0, len(codes))
+	for _, code := range codes {
+		t, _ := errorType(code, language, base)
+		types = append(types, t)
+	}
+	return types
+}
+
+// Identifica as requisições da documentação dos erros pelo caminho (/errors ou /errors/{code}),
+// retornando o código informado ou vazio para a lista. O caminho pode ter o prefixo do estágio
+// do API Gateway.
+func errorTypeCode(path string) (string, bool) {
+	path = strings.TrimSuffix(path, "/")
+	if strings.HasSuffix(path, strings.TrimSuffix(errorTypePath, "/")) {
+		return "", true
+	}
+	i := strings.LastIndex(path, errorTypePath)
+	if i < 0 || strings.Contains(path[i+len(errorTypePath):], "/") {
+		return "", false
+	}
+	return path[i+len(errorTypePath):], true
+}
+
+// Escolhe o idioma das mensagens pelo cabeçalho Accept-Language, respeitando os pesos (q).
+// Qualquer variante do português usa pt-BR e os demais idiomas usam inglês.
+func negotiateLanguage(header string) string {
+	best, bestWeight := LanguageEnglish, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil {
+				weight = value
+			}
+		}
+		var language string
+		switch tag = strings.ToLower(tag); {
+		case tag == "pt" || strings.HasPrefix(tag, "pt-"):
+			language = LanguagePortuguese
+		case tag == "en" || strings.HasPrefix(tag, "en-") || tag == "*":
+			language = LanguageEnglish
+		default:
+			continue
+		}
+		if weight > bestWeight {
+			best, bestWeight = language, weight
+		}
+	}
+	return best
+}
+
+// Escreve a resposta do erro do catálogo no idioma do cabeçalho Accept-Language da requisição.
+func (p *HttpHandler) problem(ctx context.Context, w http.ResponseWriter, r *http.Request, code ErrorCode, args ...any) error {
+	language := negotiateLanguage(r.Header.Get("Accept-Language"))
+	response := newProblem(code, language, p.config.ErrorBaseURL, r.URL.String(), args...)
+	w.Header().Set("Content-Language", language)
+	return p.toJson(ctx, w, response, response.Status)
+}
+
+// Retorna a base dos endereços da documentação dos erros: a URL configurada ou, sem ela,
+// o prefixo do caminho externo da requisição, como o estágio do API Gateway.
+func (p *LambdaHandler) errorBase(request *lambdaRequest) string {
+	if p.config.ErrorBaseURL != "" {
+		return p.config.ErrorBaseURL
+	}
+	return request.basePath
+}
+
+// Retorna a resposta do erro do catálogo no idioma do cabeçalho Accept-Language da requisição.
+func (p *LambdaHandler) problem(ctx context.Context, request *lambdaRequest, code ErrorCode, args ...any) (lambdaResponse, error) {
+	language := negotiateLanguage(request.headers.Get("Accept-Language"))
+	response := newProblem(code, language, p.errorBase(request), request.path, args...)
+	result, err := p.toJson(ctx, response, response.Status)
+	if err == nil {
+		result.Headers.Set("Content-Language", language)
+	}
+	return result, err
+}
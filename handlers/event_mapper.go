@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"api/models"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Regra de mapeamento com a expressão regular já compilada.
+type compiledRule struct {
+	rule    models.MappingRule
+	pattern *regexp.Regexp
+}
+
+// Converte sinais da AWS em eventos aplicando as regras de mapeamento configuradas.
+type eventMapper struct {
+	rules []compiledRule
+}
+
+// Entrada do mapeamento, com o texto usado na expressão regular e o documento usado no caminho JSON.
+type mappingInput struct {
+	// origem do sinal (source ou log group)
+	source string
+	// detail-type do EventBridge
+	detailType string
+	// texto avaliado pela expressão regular
+	text string
+	// documento avaliado pelos caminhos JSON
+	document interface{}
+	// data padrão do evento
+	date time.Time
+	// mensagem padrão do evento
+	message string
+}
+
+// Cria o mapeador compilando as expressões regulares das regras.
+// Entra em pânico se alguma regra for inválida, pois as regras devem ser validadas na configuração.
+func newEventMapper(rules []models.MappingRule) *eventMapper {
+	m := &eventMapper{}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			panic(err)
+		}
+		compiled := compiledRule{rule: rule}
+		if rule.Pattern != "" {
+			compiled.pattern = regexp.MustCompile(rule.Pattern)
+		}
+		m.rules = append(m.rules, compiled)
+	}
+	return m
+}
+
+// Aplica a primeira regra compatível com a entrada.
+// Retorna nil se nenhuma regra for compatível.
+func (m *eventMapper) mapInput(input *mappingInput) (*models.Event, string, error) {
+	for _, compiled := range m.rules {
+		rule := compiled.rule
+		if rule.Source != "" && rule.Source != input.source {
+			continue
+		}
+		if rule.DetailType != "" && rule.DetailType != input.detailType {
+			continue
+		}
+		var match []int
+		if compiled.pattern != nil {
+			match = compiled.pattern.FindStringSubmatchIndex(input.text)
+			if match == nil {
+				continue
+			}
+		}
+		event, err := m.apply(compiled, input, match)
+		return event, rule.Name, err
+	}
+	return nil, "", nil
+}
+
+// Monta o evento avaliando as expressões da regra.
+func (m *eventMapper) apply(compiled compiledRule, input *mappingInput, match []int) (*models.Event, error) {
+	rule := compiled.rule
+	eval := func(expr string) (interface{}, bool) {
+		if expr == "" {
+			return nil, false
+		}
+		if strings.HasPrefix(expr, "$.") || expr == "$" {
+			return jsonPath(input.document, expr)
+		}
+		if compiled.pattern != nil && match != nil {
+			return string(compiled.pattern.ExpandString(nil, expr, input.text, match)), true
+		}
+		return expr, true
+	}
+	event := &models.Event{
+		Date:          input.date,
+		StatusMessage: input.message,
+	}
+	if v, ok := eval(rule.Date); ok {
+		date, err := toTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date on mapping rule {%s}, %w", rule.Name, err)
+		}
+		event.Date = date
+	}
+	if v, ok := eval(rule.StatusCode); ok {
+		statusCode, err := toInt(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code on mapping rule {%s}, %w", rule.Name, err)
+		}
+		event.StatusCode = statusCode
+	}
+	if v, ok := eval(rule.StatusMessage); ok {
+		event.StatusMessage = toString(v)
+	}
+	for key, expr := range rule.Metadata {
+		if v, ok := eval(expr); ok {
+			if event.Metadata == nil {
+				event.Metadata = make(map[string]string)
+			}
+			event.Metadata[key] = toString(v)
+		}
+	}
+	return event, nil
+}
+
+// Avalia um caminho JSON simples no formato $.campo.subcampo[0].
+func jsonPath(document interface{}, path string) (interface{}, bool) {
+	current := document
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return current, current != nil
+	}
+	for _, part := range strings.Split(path, ".") {
+		name, indexes := part, []int{}
+		if i := strings.Index(part, "["); i >= 0 {
+			name = part[:i]
+			for _, idx := range strings.Split(strings.TrimSuffix(part[i+1:], "]"), "][") {
+				n, err := strconv.Atoi(idx)
+				if err != nil {
+					return nil, false
+				}
+				indexes = append(indexes, n)
+			}
+		}
+		if name != "" {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = object[name]; !ok {
+				return nil, false
+			}
+		}
+		for _, n := range indexes {
+			list, ok := current.([]interface{})
+			if !ok || n < 0 || n >= len(list) {
+				return nil, false
+			}
+			current = list[n]
+		}
+	}
+	return current, current != nil
+}
+
+// Converte o valor para texto.
+func toString(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
+
+// Converte o valor para inteiro.
+func toInt(v interface{}) (int, error) {
+	switch value := v.(type) {
+	case float64:
+		return int(value), nil
+	case string:
+		return strconv.Atoi(strings.TrimSpace(value))
+	default:
+		return 0, fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+// Converte o valor para data, aceitando RFC3339 ou epoch em segundos ou milissegundos.
+func toTime(v interface{}) (time.Time, error) {
+	var epoch int64
+	switch value := v.(type) {
+	case float64:
+		epoch = int64(value)
+	case string:
+		value = strings.TrimSpace(value)
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return t, nil
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unsupported date %q", value)
+		}
+		epoch = n
+	default:
+		return time.Time{}, fmt.Errorf("unsupported date %v", v)
+	}
+	// valores acima de 1e11 são considerados milissegundos
+	if epoch > 1e11 {
+		return time.UnixMilli(epoch).UTC(), nil
+	}
+	return time.Unix(epoch, 0).UTC(), nil
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"api/interfaces"
+	"api/models"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Configuração do EventBridgeHandler.
+type EventBridgeHandlerConfig struct {
+	// repositório de dados
+	Repository interfaces.Repository
+	// regras de mapeamento dos eventos
+	Rules []models.MappingRule
+}
+
+// Estrutura do EventBridgeHandler.
+type EventBridgeHandler struct {
+	// configuração do handler
+	config *EventBridgeHandlerConfig
+	// mapeador de eventos
+	mapper *eventMapper
+	// configura o tracer
+	tracer trace.Tracer
+	// metricas de eventos
+	eventCounter metric.Int64Counter
+}
+
+// Cria uma nova instância do EventBridgeHandler.
+func NewEventBridgeHandler(config *EventBridgeHandlerConfig) *EventBridgeHandler {
+	h := &EventBridgeHandler{
+		config: config,
+		mapper: newEventMapper(config.Rules),
+		tracer: otel.Tracer("eventbridge.handler"),
+	}
+	// configura as metricas
+	meter := otel.Meter("eventbridge.consumer.metrics")
+	if counter, err := meter.Int64Counter("custom.eventbridge.events.total",
+		metric.WithDescription("The number of EventBridge events processed"),
+		metric.WithUnit("{events}")); err == nil {
+		h.eventCounter = counter
+	} else {
+		panic(err)
+	}
+	return h
+}
+
+// Processa um evento do EventBridge, convertendo-o pelas regras de mapeamento e salvando no repositório.
+// Eventos sem regra compatível são ignorados.
+func (p *EventBridgeHandler) HandleRequest(ctx context.Context, cloudWatchEvent events.CloudWatchEvent) (err error) {
+	ctx, span := p.tracer.Start(ctx, "HandleRequest",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(lambdaInvocationAttributes(ctx)...),
+		trace.WithAttributes(
+			attribute.String("faas.trigger", "pubsub"),
+			attribute.String("cloud.event.id", cloudWatchEvent.ID),
+			attribute.String("cloud.event.source", cloudWatchEvent.Source),
+			attribute.String("cloud.event.type", cloudWatchEvent.DetailType),
+		),
+	)
+	defer span.End()
+	result := "success"
+	defer func() {
+		p.eventCounter.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("cloud.event.source", cloudWatchEvent.Source),
+			attribute.String("result", result),
+		))
+	}()
+	// o documento contém o evento completo para permitir caminhos como $.detail.state
+	var document interface{}
+	data, err := json.Marshal(cloudWatchEvent)
+	if err == nil {
+		err = json.Unmarshal(data, &document)
+	}
+	if err != nil {
+		result = "invalid"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to decode event")
+		slog.ErrorContext(ctx, "unable to decode event", "event_id", cloudWatchEvent.ID, "error", err)
+		return err
+	}
+	event, rule, err := p.mapper.mapInput(&mappingInput{
+		source:     cloudWatchEvent.Source,
+		detailType: cloudWatchEvent.DetailType,
+		text:       string(cloudWatchEvent.Detail),
+		document:   document,
+		date:       cloudWatchEvent.Time,
+		message:    cloudWatchEvent.DetailType,
+	})
+	if err == nil && event != nil {
+		err = event.Validate()
+	}
+	if err != nil {
+		// uma nova tentativa não teria sucesso, então o evento é descartado
+		result = "invalid"
+		span.AddEvent(
+			"record mapping failed",
+			trace.WithAttributes(attribute.String("error", err.Error())),
+		)
+		slog.ErrorContext(ctx, "unable to map event", "event_id", cloudWatchEvent.ID, "error", err)
+		return nil
+	}
+	if event == nil {
+		result = "unmatched"
+		span.AddEvent("no mapping rule matched")
+		return nil
+	}
+	span.SetAttributes(attribute.String("mapping.rule", rule))
+	// o id é derivado do id do evento para que novas tentativas não dupliquem registros
+	event.Id = uuid.NewSHA1(uuid.NameSpaceOID, []byte(cloudWatchEvent.ID)).String()
+	if err := p.config.Repository.Save(ctx, event); err != nil {
+		result = "failure"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to save record in repository")
+		slog.ErrorContext(ctx, "unable to save record of event in repository", "event_id", cloudWatchEvent.ID, "error", err)
+		return err
+	}
+	slog.InfoContext(ctx, "event saved", "event_id", cloudWatchEvent.ID, "id", event.Id, "rule", rule, "date", event.Date.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"api/models"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Define o escritor de eventos usado pela exportação em streaming.
+type exportWriter interface {
+	// escreve uma página de eventos
+	Write(events []*models.Event) error
+	// finaliza a escrita, descarregando os dados pendentes
+	Close() error
+}
+
+// Retorna o tipo de conteúdo e a extensão do arquivo do formato informado.
+func exportFormat(format string) (contentType string, extension string, ok bool) {
+	switch format {
+	case "", "ndjson":
+		return "application/x-ndjson", "ndjson", true
+	case "csv":
+		return "text/csv; charset=utf-8", "csv", true
+	}
+	return "", "", false
+}
+
+// Cria o escritor do formato informado.
+func newExportWriter(format string, w io.Writer, metadataColumns []string) exportWriter {
+	if format == "csv" {
+		return &csvExportWriter{writer: csv.NewWriter(w), columns: metadataColumns}
+	}
+	return &ndjsonExportWriter{encoder: json.NewEncoder(w)}
+}
+
+// Escreve um evento JSON por linha.
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+// Escreve uma página de eventos.
+func (e *ndjsonExportWriter) Write(events []*models.Event) error {
+	for _, event := range events {
+		if err := e.encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Não há dados pendentes no formato NDJSON.
+func (e *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// Escreve os eventos em CSV com os metadados em colunas próprias.
+// As colunas de metadados são as informadas ou as encontradas na primeira página;
+// chaves que não possuem coluna própria são gravadas em JSON na última coluna.
+type csvExportWriter struct {
+	writer  *csv.Writer
+	columns []string
+	header  bool
+}
+
+// Escreve uma página de eventos.
+func (e *csvExportWriter) Write(events []*models.Event) error {
+	if !e.header {
+		if err := e.writeHeader(events); err != nil {
+			return err
+		}
+	}
+	for _, event := range events {
+		record := []string{
+			event.Id,
+			event.Date.Format(time.RFC3339Nano),
+			strconv.Itoa(event.StatusCode),
+			event.StatusMessage,
+			strconv.FormatInt(event.Expiration, 10),
+		}
+		for _, column := range e.columns {
+			record = append(record, event.Metadata[column])
+		}
+		extra := make(map[string]string)
+		for k, v := range event.Metadata {
+			if !slices.Contains(e.columns, k) {
+				extra[k] = v
+			}
+		}
+		if len(extra) > 0 {
+			data, err := json.Marshal(extra)
+			if err != nil {
+				return err
+			}
+			record = append(record, string(data))
+		} else {
+			record = append(record, "")
+		}
+		if err := e.writer.Write(record); err != nil {
+			return err
+		}
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// Escreve o cabeçalho, descobrindo as colunas de metadados se necessário.
+func (e *csvExportWriter) writeHeader(events []*models.Event) error {
+	if e.columns == nil {
+		for _, event := range events {
+			for k := range event.Metadata {
+				if !slices.Contains(e.columns, k) {
+					e.columns = append(e.columns, k)
+				}
+			}
+		}
+		slices.Sort(e.columns)
+	}
+	header := []string{"id", "date", "statusCode", "statusMessage", "expiration"}
+	for _, column := range e.columns {
+		header = append(header, fmt.Sprintf("metadata.%s", column))
+	}
+	header = append(header, "metadata")
+	e.header = true
+	return e.writer.Write(header)
+}
+
+// Garante que o cabeçalho seja escrito mesmo sem eventos e descarrega os dados pendentes.
+func (e *csvExportWriter) Close() error {
+	if !e.header {
+		if err := e.writeHeader(nil); err != nil {
+			return err
+		}
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// Converte a lista de colunas informada na query string.
+func parseMetadataColumns(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	columns := make([]string, 0)
+	for _, column := range strings.Split(value, ",") {
+		if column = strings.TrimSpace(column); column != "" && !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
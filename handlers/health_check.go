@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"api/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// Tempo limite de cada verificação de saúde.
+const healthCheckTimeout = 2 * time.Second
+
+// Define a verificação de saúde de uma dependência da aplicação.
+type HealthCheck struct {
+	// nome da dependência
+	Name string
+	// indica se a falha torna a aplicação não pronta para receber requisições
+	Critical bool
+	// executa a verificação
+	Check func(ctx context.Context) error
+}
+
+// Executa as verificações em paralelo e monta a resposta.
+// A aplicação só é considerada pronta se todas as verificações críticas passarem.
+func runHealthChecks(ctx context.Context, checks []HealthCheck) *models.HealthResponse {
+	response := &models.HealthResponse{
+		Status: models.HealthStatusOk,
+		Checks: make(map[string]*models.HealthCheckResult, len(checks)),
+	}
+	results := make([]*models.HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			result := &models.HealthCheckResult{Status: models.HealthStatusOk, Critical: check.Critical}
+			if err := check.Check(ctx); err != nil {
+				result.Status = models.HealthStatusFail
+				result.Error = err.Error()
+			}
+			result.DurationMs = time.Since(start).Milliseconds()
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+	for i, check := range checks {
+		response.Checks[check.Name] = results[i]
+		if results[i].Critical && results[i].Status != models.HealthStatusOk {
+			response.Status = models.HealthStatusFail
+		}
+	}
+	return response
+}
@@ -3,17 +3,20 @@ package handlers
 import (
 	"api/interfaces"
 	"api/models"
+	"api/repositories"
+	"api/telemetry"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -38,6 +41,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Retorna o ResponseWriter original para uso com o http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Sobrescreve o método Write para garantir que o status code seja capturado mesmo quando WriteHeader não é chamado explicitamente.
 func (rw *responseWriter) Write(b []byte) (int, error) {
 	if !rw.wroteHeader {
@@ -46,16 +54,31 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
 
+// Tempo padrão para escrita da exportação em streaming.
+const defaultExportWriteTimeout = 10 * time.Minute
+
 // Configuração do HttpHandler.
 type HttpHandlerConfig struct {
 	// repositório de dados
 	Repository interfaces.Repository
+	// tempo limite para escrita da exportação em streaming
+	ExportWriteTimeout time.Duration
+	// rejeita a exportação sem status code, que percorre a tabela inteira com Scan
+	ExportRequireStatusCode bool
+	// URL absoluta onde a documentação dos erros é servida, vazio usa os caminhos a partir da raiz
+	ErrorBaseURL string
+	// verificações de saúde adicionais à do repositório
+	HealthChecks []HealthCheck
 }
 
 // Estrutura do HttpHandler.
 type HttpHandler struct {
 	// configuração do handler
 	config *HttpHandlerConfig
+	// tempo limite para escrita da exportação, alterável sem reiniciar a aplicação
+	exportWriteTimeout atomic.Int64
+	// indica que o encerramento foi iniciado e a aplicação não deve receber novas requisições
+	shuttingDown atomic.Bool
 	// configura o tracer
 	tracer trace.Tracer
 	// metricas de requisições
@@ -69,6 +92,7 @@ func NewHttpHandler(config *HttpHandlerConfig) *HttpHandler {
 		config: config,
 		tracer: otel.Tracer("http.handler"),
 	}
+	h.SetExportWriteTimeout(config.ExportWriteTimeout)
 	// configura as metricas
 	meter := otel.Meter("http.server.metrics")
 	if counter, err := meter.Int64Counter("custom.http.requests.total",
@@ -88,6 +112,20 @@ func NewHttpHandler(config *HttpHandlerConfig) *HttpHandler {
 	return h
 }
 
+// Altera o tempo limite para escrita da exportação em streaming.
+func (p *HttpHandler) SetExportWriteTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultExportWriteTimeout
+	}
+	p.exportWriteTimeout.Store(int64(timeout))
+}
+
+// Sinaliza o início do encerramento, fazendo a verificação de prontidão falhar
+// para que os balanceadores deixem de enviar requisições.
+func (p *HttpHandler) StartShutdown() {
+	p.shuttingDown.Store(true)
+}
+
 // helper para adicionar metricas na rota.
 func (p *HttpHandler) routeHandler(route string, h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -96,13 +134,14 @@ func (p *HttpHandler) routeHandler(route string, h http.Handler) http.Handler {
 			ResponseWriter: w,
 			statusCode:     http.StatusOK,
 		}
+		if identity := CallerIdentity(r.Context()); identity != "" {
+			trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("enduser.id", identity))
+		}
 		h.ServeHTTP(rw, r)
 		duration := time.Since(start)
-		attrs := []attribute.KeyValue{
-			attribute.String("http.method", strings.ToUpper(r.Method)),
-			attribute.String("http.route", route),
-			attribute.String("http.status_code", fmt.Sprintf("%d", rw.statusCode)),
-		}
+		attrs := telemetry.HTTPServerAttributes(r.Method, route, rw.statusCode)
+		// o span do otelhttp recebe também os atributos da convenção anterior, se habilitada
+		trace.SpanFromContext(r.Context()).SetAttributes(attrs...)
 		p.requestCounter.Add(r.Context(), 1, metric.WithAttributes(attrs...))
 		p.requestHistogram.Record(r.Context(), float64(duration.Milliseconds()), metric.WithAttributes(attrs...))
 	})
@@ -110,12 +149,23 @@ func (p *HttpHandler) routeHandler(route string, h http.Handler) http.Handler {
 
 // Registra os handlers HTTP no roteador fornecido.
 func (p *HttpHandler) HandleRequest(router *http.ServeMux) {
-	router.Handle("GET /health", otelhttp.NewHandler(p.routeHandler("/health", http.HandlerFunc(p.handleHealth)), ""))
-	router.Handle("GET /eventos", otelhttp.NewHandler(p.routeHandler("/eventos", http.HandlerFunc(p.handleFind)), ""))
-	router.Handle("GET /eventos/{id}", otelhttp.NewHandler(p.routeHandler("/eventos/{id}", http.HandlerFunc(p.handleGet)), ""))
-	router.Handle("POST /eventos", otelhttp.NewHandler(p.routeHandler("/eventos", http.HandlerFunc(p.handlePost)), ""))
-	router.Handle("PUT /eventos/{id}", otelhttp.NewHandler(p.routeHandler("/eventos/{id}", http.HandlerFunc(p.handlePut)), ""))
-	router.Handle("DELETE /eventos/{id}", otelhttp.NewHandler(p.routeHandler("/eventos/{id}", http.HandlerFunc(p.handleDelete)), ""))
+	p.handle(router, "GET", "/health", p.handleHealth)
+	p.handle(router, "GET", "/health/live", p.handleLive)
+	p.handle(router, "GET", "/health/ready", p.handleReady)
+	p.handle(router, "GET", "/eventos", p.handleFind)
+	p.handle(router, "GET", "/eventos/export", p.handleExport)
+	p.handle(router, "GET", "/eventos/{id}", p.handleGet)
+	p.handle(router, "POST", "/eventos", p.handlePost)
+	p.handle(router, "PUT", "/eventos/{id}", p.handlePut)
+	p.handle(router, "DELETE", "/eventos/{id}", p.handleDelete)
+	p.handle(router, "GET", "/errors", p.handleErrorTypes)
+	p.handle(router, "GET", "/errors/{code}", p.handleErrorType)
+}
+
+// Registra o handler da rota com as métricas da requisição.
+// O span do servidor é iniciado antes do roteamento e nomeado pelo padrão da rota.
+func (p *HttpHandler) handle(router *http.ServeMux, method string, route string, h http.HandlerFunc) {
+	router.Handle(method+" "+route, p.routeHandler(route, h))
 }
 
 // Processa requisições para checagem de saúde da aplicação.
@@ -126,6 +176,42 @@ func (p *HttpHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("OK"))
 }
 
+// Processa requisições de liveness, que indicam apenas que o processo está respondendo.
+func (p *HttpHandler) handleLive(w http.ResponseWriter, r *http.Request) {
+	ctx, span := p.tracer.Start(r.Context(), "handleLive")
+	defer span.End()
+	p.toJson(ctx, w, models.HealthResponse{Status: models.HealthStatusOk}, http.StatusOK)
+}
+
+// Processa requisições de readiness, verificando as dependências da aplicação.
+// Falha assim que o encerramento é iniciado.
+func (p *HttpHandler) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, span := p.tracer.Start(r.Context(), "handleReady")
+	defer span.End()
+	if p.shuttingDown.Load() {
+		span.AddEvent("shutting down")
+		p.toJson(ctx, w, models.HealthResponse{
+			Status: models.HealthStatusFail,
+			Checks: map[string]*models.HealthCheckResult{
+				"shutdown": {Status: models.HealthStatusFail, Critical: true, Error: "server is shutting down"},
+			},
+		}, http.StatusServiceUnavailable)
+		return
+	}
+	checks := append([]HealthCheck{{
+		Name:     "repository",
+		Critical: true,
+		Check:    p.config.Repository.Ping,
+	}}, p.config.HealthChecks...)
+	response := runHealthChecks(ctx, checks)
+	if response.Status != models.HealthStatusOk {
+		span.SetStatus(codes.Error, "readiness check failed")
+		p.toJson(ctx, w, response, http.StatusServiceUnavailable)
+		return
+	}
+	p.toJson(ctx, w, response, http.StatusOK)
+}
+
 // Processa requisições GET.
 func (p *HttpHandler) handleGet(w http.ResponseWriter, r *http.Request) {
 	ctx, span := p.tracer.Start(r.Context(), "handleGet")
@@ -133,38 +219,20 @@ func (p *HttpHandler) handleGet(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
 		span.AddEvent("{id} not provided")
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Bad Request",
-			Status:   http.StatusBadRequest,
-			Detail:   "Missing event ID in URL",
-			Instance: r.URL.String(),
-		}, http.StatusBadRequest)
+		p.problem(ctx, w, r, ErrorMissingEventID)
 		return
 	}
 	event, err := p.config.Repository.Get(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		span.AddEvent("record not found")
+		p.repositoryError(ctx, w, r, err)
+		return
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to get record from repository")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to get record from repository, %s", err))
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Internal Server Error",
-			Status:   http.StatusInternalServerError,
-			Detail:   err.Error(),
-			Instance: r.URL.String(),
-		}, http.StatusInternalServerError)
-		return
-	}
-	if event == nil {
-		span.AddEvent("record not found")
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Not Found",
-			Status:   http.StatusNotFound,
-			Detail:   "Event not found",
-			Instance: r.URL.String(),
-		}, http.StatusNotFound)
+		slog.ErrorContext(ctx, "unable to get record from repository", "error", err)
+		p.repositoryError(ctx, w, r, err)
 		return
 	}
 	p.toJson(ctx, w, event, http.StatusOK)
@@ -178,7 +246,7 @@ func (p *HttpHandler) handlePost(w http.ResponseWriter, r *http.Request) {
 	if err := p.fromJson(ctx, w, r, event); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to decode json")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to decode json, %s", err))
+		slog.ErrorContext(ctx, "unable to decode json", "error", err)
 		return
 	}
 	if err := event.Validate(); err != nil {
@@ -186,13 +254,7 @@ func (p *HttpHandler) handlePost(w http.ResponseWriter, r *http.Request) {
 			"record validation failed",
 			trace.WithAttributes(attribute.String("error", err.Error())),
 		)
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Invalid Body",
-			Status:   http.StatusBadRequest,
-			Detail:   err.Error(),
-			Instance: r.URL.String(),
-		}, http.StatusBadRequest)
+		p.problem(ctx, w, r, ErrorInvalidEvent, err.Error())
 		return
 	}
 	event.Id = uuid.New().String()
@@ -200,14 +262,8 @@ func (p *HttpHandler) handlePost(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to save record in repository")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to save record in repository, %s", err))
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Internal Server Error",
-			Status:   http.StatusInternalServerError,
-			Detail:   err.Error(),
-			Instance: r.URL.String(),
-		}, http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "unable to save record in repository", "error", err)
+		p.repositoryError(ctx, w, r, err)
 		return
 	}
 	p.toJson(ctx, w, event, http.StatusCreated)
@@ -220,20 +276,14 @@ func (p *HttpHandler) handlePut(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
 		span.AddEvent("{id} not provided")
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Bad Request",
-			Status:   http.StatusBadRequest,
-			Detail:   "Missing event ID in URL",
-			Instance: r.URL.String(),
-		}, http.StatusBadRequest)
+		p.problem(ctx, w, r, ErrorMissingEventID)
 		return
 	}
 	event := &models.Event{}
 	if err := p.fromJson(ctx, w, r, event); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to decode json")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to decode json, %s", err))
+		slog.ErrorContext(ctx, "unable to decode json", "error", err)
 		return
 	}
 	if err := event.Validate(); err != nil {
@@ -241,13 +291,7 @@ func (p *HttpHandler) handlePut(w http.ResponseWriter, r *http.Request) {
 			"record validation failed",
 			trace.WithAttributes(attribute.String("error", err.Error())),
 		)
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Invalid Body",
-			Status:   http.StatusBadRequest,
-			Detail:   err.Error(),
-			Instance: r.URL.String(),
-		}, http.StatusBadRequest)
+		p.problem(ctx, w, r, ErrorInvalidEvent, err.Error())
 		return
 	}
 	event.Id = id
@@ -255,14 +299,8 @@ func (p *HttpHandler) handlePut(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to save record in repository")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to save record in repository, %s", err))
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Internal Server Error",
-			Status:   http.StatusInternalServerError,
-			Detail:   err.Error(),
-			Instance: r.URL.String(),
-		}, http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "unable to save record in repository", "error", err)
+		p.repositoryError(ctx, w, r, err)
 		return
 	}
 	p.toJson(ctx, w, event, http.StatusCreated)
@@ -275,38 +313,20 @@ func (p *HttpHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
 		span.AddEvent("{id} not provided")
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Bad Request",
-			Status:   http.StatusBadRequest,
-			Detail:   "Missing event ID in URL",
-			Instance: r.URL.String(),
-		}, http.StatusBadRequest)
+		p.problem(ctx, w, r, ErrorMissingEventID)
+		return
+	}
+	_, err := p.config.Repository.Delete(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		span.AddEvent("record not found")
+		p.repositoryError(ctx, w, r, err)
 		return
 	}
-	event, err := p.config.Repository.Delete(ctx, id)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to delete record from repository")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to delete record from repository, %s", err))
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Internal Server Error",
-			Status:   http.StatusInternalServerError,
-			Detail:   err.Error(),
-			Instance: r.URL.String(),
-		}, http.StatusInternalServerError)
-		return
-	}
-	if event == nil {
-		span.AddEvent("record not found")
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Not Found",
-			Status:   http.StatusNotFound,
-			Detail:   "Event not found",
-			Instance: r.URL.String(),
-		}, http.StatusNotFound)
+		slog.ErrorContext(ctx, "unable to delete record from repository", "error", err)
+		p.repositoryError(ctx, w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -323,13 +343,7 @@ func (p *HttpHandler) handleFind(w http.ResponseWriter, r *http.Request) {
 			"unable to parse form data",
 			trace.WithAttributes(attribute.String("error", err.Error())),
 		)
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Invalid Body",
-			Status:   http.StatusBadRequest,
-			Detail:   err.Error(),
-			Instance: r.URL.String(),
-		}, http.StatusBadRequest)
+		p.problem(ctx, w, r, ErrorInvalidQuery, err.Error())
 		return
 	}
 	// configura valores default caso sejam informados
@@ -345,13 +359,7 @@ func (p *HttpHandler) handleFind(w http.ResponseWriter, r *http.Request) {
 				"unable to parse value of {from} to RFC3339",
 				trace.WithAttributes(attribute.String("error", err.Error())),
 			)
-			p.toJson(ctx, w, models.ErrorResponse{
-				Type:     "about:blank",
-				Title:    "Invalid Body",
-				Status:   http.StatusBadRequest,
-				Detail:   fmt.Sprintf("parameter {from} invalid, %s", err.Error()),
-				Instance: r.URL.String(),
-			}, http.StatusBadRequest)
+			p.problem(ctx, w, r, ErrorInvalidParameter, "from", err.Error())
 			return
 		}
 	}
@@ -362,13 +370,7 @@ func (p *HttpHandler) handleFind(w http.ResponseWriter, r *http.Request) {
 				"unable to parse value of {to} to RFC3339",
 				trace.WithAttributes(attribute.String("error", err.Error())),
 			)
-			p.toJson(ctx, w, models.ErrorResponse{
-				Type:     "about:blank",
-				Title:    "Invalid Body",
-				Status:   http.StatusBadRequest,
-				Detail:   fmt.Sprintf("parameter {to} invalid, %s", err.Error()),
-				Instance: r.URL.String(),
-			}, http.StatusBadRequest)
+			p.problem(ctx, w, r, ErrorInvalidParameter, "to", err.Error())
 			return
 		}
 	}
@@ -379,34 +381,178 @@ func (p *HttpHandler) handleFind(w http.ResponseWriter, r *http.Request) {
 				"unable to parse value of {statusCode} to interger",
 				trace.WithAttributes(attribute.String("error", err.Error())),
 			)
-			p.toJson(ctx, w, models.ErrorResponse{
-				Type:     "about:blank",
-				Title:    "Invalid Body",
-				Status:   http.StatusBadRequest,
-				Detail:   fmt.Sprintf("parameter {statusCode} invalid, %s", err.Error()),
-				Instance: r.URL.String(),
-			}, http.StatusBadRequest)
+			p.problem(ctx, w, r, ErrorInvalidParameter, "statusCode", err.Error())
 			return
 		}
 	}
+	if from.After(to) {
+		span.AddEvent("{from} is after {to}")
+		p.problem(ctx, w, r, ErrorInvalidDateRange)
+		return
+	}
 	events, err := p.config.Repository.FindByDateAndReturnCode(ctx, from, to, statusCode)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to find record in repository")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to find record in repository, %s", err))
-		p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Internal Server Error",
-			Status:   http.StatusInternalServerError,
-			Detail:   err.Error(),
-			Instance: r.URL.String(),
-		}, http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "unable to find record in repository", "error", err)
+		p.repositoryError(ctx, w, r, err)
 		return
 	}
 	p.toJson(ctx, w, events, http.StatusOK)
 }
 
+// Processa requisições de exportação em streaming nos formatos NDJSON e CSV.
+// As linhas são enviadas à medida que as páginas do repositório chegam e a
+// desconexão do cliente cancela a consulta.
+func (p *HttpHandler) handleExport(w http.ResponseWriter, r *http.Request) {
+	ctx, span := p.tracer.Start(r.Context(), "handleExport")
+	defer span.End()
+	// deve fazer o parser para validar se não há erros no formulario
+	err := r.ParseForm()
+	if err != nil {
+		span.AddEvent(
+			"unable to parse form data",
+			trace.WithAttributes(attribute.String("error", err.Error())),
+		)
+		p.problem(ctx, w, r, ErrorInvalidQuery, err.Error())
+		return
+	}
+	// configura valores default caso sejam informados
+	from := time.Now().Add(-1 * time.Hour)
+	to := time.Now()
+	var statusCode *int
+	format := strings.ToLower(strings.TrimSpace(r.Form.Get("format")))
+	contentType, extension, ok := exportFormat(format)
+	if !ok {
+		span.AddEvent("unsupported format")
+		p.problem(ctx, w, r, ErrorUnsupportedExportFormat)
+		return
+	}
+	// trata os valores informados atualizando o default
+	// se necessário
+	if v := strings.TrimSpace(r.Form.Get("from")); v != "" {
+		from, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			span.AddEvent(
+				"unable to parse value of {from} to RFC3339",
+				trace.WithAttributes(attribute.String("error", err.Error())),
+			)
+			p.problem(ctx, w, r, ErrorInvalidParameter, "from", err.Error())
+			return
+		}
+	}
+	if v := strings.TrimSpace(r.Form.Get("to")); v != "" {
+		to, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			span.AddEvent(
+				"unable to parse value of {to} to RFC3339",
+				trace.WithAttributes(attribute.String("error", err.Error())),
+			)
+			p.problem(ctx, w, r, ErrorInvalidParameter, "to", err.Error())
+			return
+		}
+	}
+	if v := strings.TrimSpace(r.Form.Get("statusCode")); v != "" {
+		value, err := strconv.Atoi(v)
+		if err != nil {
+			span.AddEvent(
+				"unable to parse value of {statusCode} to interger",
+				trace.WithAttributes(attribute.String("error", err.Error())),
+			)
+			p.problem(ctx, w, r, ErrorInvalidParameter, "statusCode", err.Error())
+			return
+		}
+		statusCode = &value
+	}
+	if statusCode == nil && p.config.ExportRequireStatusCode {
+		span.AddEvent("{statusCode} is required")
+		p.problem(ctx, w, r, ErrorStatusCodeRequired)
+		return
+	}
+	if from.After(to) {
+		span.AddEvent("{from} is after {to}")
+		p.problem(ctx, w, r, ErrorInvalidDateRange)
+		return
+	}
+	// a exportação pode demorar mais que o WriteTimeout do servidor
+	timeout := time.Duration(p.exportWriteTimeout.Load())
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		slog.WarnContext(ctx, "unable to extend write deadline", "error", err)
+	}
+	// o cabeçalho só é enviado com a primeira página para que erros iniciais ainda retornem 500
+	writer := newExportWriter(format, w, parseMetadataColumns(r.Form.Get("metadata")))
+	started := false
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="eventos.%s"`, extension))
+		w.WriteHeader(http.StatusOK)
+	}
+	rows := 0
+	err = p.config.Repository.StreamByDate(ctx, from, to, statusCode, func(events []*models.Event) error {
+		start()
+		if err := writer.Write(events); err != nil {
+			return err
+		}
+		rows += len(events)
+		return controller.Flush()
+	})
+	span.SetAttributes(attribute.Int("export.rows", rows))
+	if err == nil {
+		start()
+		err = writer.Close()
+	}
+	if err == nil {
+		return
+	}
+	// o cliente desconectou, não há a quem responder
+	if ctx.Err() != nil {
+		span.AddEvent("client disconnected")
+		slog.WarnContext(ctx, "export interrupted", "rows", rows, "error", ctx.Err())
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "unable to export records from repository")
+	slog.ErrorContext(ctx, "unable to export records from repository", "error", err)
+	if !started {
+		p.repositoryError(ctx, w, r, err)
+		return
+	}
+	// a resposta já foi iniciada, então a conexão é abortada para o cliente não receber um arquivo truncado como completo
+	panic(http.ErrAbortHandler)
+}
+
+// Lista a documentação dos erros da API no idioma do cabeçalho Accept-Language.
+func (p *HttpHandler) handleErrorTypes(w http.ResponseWriter, r *http.Request) {
+	ctx, span := p.tracer.Start(r.Context(), "handleErrorTypes")
+	defer span.End()
+	language := negotiateLanguage(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", language)
+	p.toJson(ctx, w, errorTypes(language, p.config.ErrorBaseURL), http.StatusOK)
+}
+
+// Retorna a documentação do erro, endereço do campo type das respostas de erro.
+func (p *HttpHandler) handleErrorType(w http.ResponseWriter, r *http.Request) {
+	ctx, span := p.tracer.Start(r.Context(), "handleErrorType")
+	defer span.End()
+	code := r.PathValue("code")
+	language := negotiateLanguage(r.Header.Get("Accept-Language"))
+	response, ok := errorType(ErrorCode(code), language, p.config.ErrorBaseURL)
+	if !ok {
+		span.AddEvent("error type not found")
+		p.problem(ctx, w, r, ErrorErrorTypeNotFound, code)
+		return
+	}
+	w.Header().Set("Content-Language", language)
+	p.toJson(ctx, w, response, http.StatusOK)
+}
+
 // Converte o corpo da requisição de JSON para o objeto fornecido.
+// Em caso de erro a resposta já é escrita e o erro da decodificação é retornado.
 func (p *HttpHandler) fromJson(ctx context.Context, w http.ResponseWriter, r *http.Request, object interface{}) error {
 	ctx, span := p.tracer.Start(ctx, "fromJson")
 	defer span.End()
@@ -415,16 +561,10 @@ func (p *HttpHandler) fromJson(ctx context.Context, w http.ResponseWriter, r *ht
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to decode json")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to decode json, %s", err))
-		return p.toJson(ctx, w, models.ErrorResponse{
-			Type:     "https://www.rfc-editor.org/rfc/rfc8259",
-			Title:    "Invalid JSON",
-			Status:   http.StatusBadRequest,
-			Detail:   err.Error(),
-			Instance: r.URL.String(),
-		}, http.StatusBadRequest)
-	}
-	return nil
+		slog.ErrorContext(ctx, "unable to decode json", "error", err)
+		p.problem(ctx, w, r, ErrorInvalidJSON, err.Error())
+	}
+	return err
 }
 
 // Converte o objeto para JSON e escreve na resposta HTTP.
@@ -437,7 +577,7 @@ func (p *HttpHandler) toJson(ctx context.Context, w http.ResponseWriter, object
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to encode json")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to encode json, %s", err))
+		slog.ErrorContext(ctx, "unable to encode json", "error", err)
 	}
 	return err
 }
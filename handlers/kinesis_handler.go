@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"api/interfaces"
+	"api/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Configuração do KinesisHandler.
+type KinesisHandlerConfig struct {
+	// repositório de dados
+	Repository interfaces.Repository
+}
+
+// Estrutura do KinesisHandler.
+type KinesisHandler struct {
+	// configuração do handler
+	config *KinesisHandlerConfig
+	// configura o tracer
+	tracer trace.Tracer
+	// metricas de registros
+	recordCounter  metric.Int64Counter
+	batchHistogram metric.Float64Histogram
+}
+
+// Cria uma nova instância do KinesisHandler.
+func NewKinesisHandler(config *KinesisHandlerConfig) *KinesisHandler {
+	h := &KinesisHandler{
+		config: config,
+		tracer: otel.Tracer("kinesis.handler"),
+	}
+	// configura as metricas
+	meter := otel.Meter("kinesis.consumer.metrics")
+	if counter, err := meter.Int64Counter("custom.kinesis.records.total",
+		metric.WithDescription("The number of Kinesis records processed"),
+		metric.WithUnit("{records}")); err == nil {
+		h.recordCounter = counter
+	} else {
+		panic(err)
+	}
+	if histogram, err := meter.Float64Histogram("custom.kinesis.batches.duration",
+		metric.WithDescription("The duration of Kinesis batch processing"),
+		metric.WithUnit("ms")); err == nil {
+		h.batchHistogram = histogram
+	} else {
+		panic(err)
+	}
+	return h
+}
+
+// Processa o lote de registros recebido do Kinesis Data Streams.
+// Registros que não podem ser decodificados ou validados são descartados, pois
+// uma nova tentativa não teria sucesso e bloquearia o shard. Os registros que
+// falharam ao salvar são reportados em BatchItemFailures pelo número de sequência.
+func (p *KinesisHandler) HandleRequest(ctx context.Context, kinesisEvent events.KinesisEvent) (response events.KinesisEventResponse, err error) {
+	ctx, span := p.tracer.Start(ctx, "HandleRequest",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(lambdaInvocationAttributes(ctx)...),
+		trace.WithAttributes(
+			attribute.String("faas.trigger", "pubsub"),
+			attribute.String("messaging.system", "aws_kinesis"),
+			attribute.Int("messaging.batch.message_count", len(kinesisEvent.Records)),
+		),
+	)
+	defer span.End()
+	start := time.Now()
+	// decodifica e valida os eventos de cada registro
+	batch := make([]*models.Event, 0, len(kinesisEvent.Records))
+	owners := make(map[*models.Event]string)
+	invalid := 0
+	for _, record := range kinesisEvent.Records {
+		records, err := p.decodeRecord(ctx, record)
+		if err != nil {
+			invalid++
+			continue
+		}
+		for _, event := range records {
+			owners[event] = record.Kinesis.SequenceNumber
+		}
+		batch = append(batch, records...)
+	}
+	// salva os eventos válidos em lote
+	failedSequences := make(map[string]bool)
+	if len(batch) > 0 {
+		failed, err := p.config.Repository.SaveBatch(ctx, batch)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "unable to save records in repository")
+			slog.ErrorContext(ctx, "unable to save records in repository", "records", len(failed), "error", err)
+		}
+		for _, event := range failed {
+			failedSequences[owners[event]] = true
+		}
+	}
+	// reporta os registros na ordem em que foram recebidos
+	for _, record := range kinesisEvent.Records {
+		if failedSequences[record.Kinesis.SequenceNumber] {
+			response.BatchItemFailures = append(response.BatchItemFailures, events.KinesisBatchItemFailure{
+				ItemIdentifier: record.Kinesis.SequenceNumber,
+			})
+		}
+	}
+	succeeded := len(kinesisEvent.Records) - invalid - len(response.BatchItemFailures)
+	p.recordCounter.Add(ctx, int64(succeeded), metric.WithAttributes(attribute.String("result", "success")))
+	p.recordCounter.Add(ctx, int64(invalid), metric.WithAttributes(attribute.String("result", "invalid")))
+	p.recordCounter.Add(ctx, int64(len(response.BatchItemFailures)), metric.WithAttributes(attribute.String("result", "failure")))
+	duration := time.Since(start)
+	p.batchHistogram.Record(ctx, float64(duration.Milliseconds()))
+	span.SetAttributes(
+		attribute.Int("messaging.batch.invalid_count", invalid),
+		attribute.Int("messaging.batch.failure_count", len(response.BatchItemFailures)),
+	)
+	slog.InfoContext(
+		ctx,
+		"batch processed",
+		"duration_ms", duration.Milliseconds(),
+		"records", len(kinesisEvent.Records),
+		"events", len(batch),
+		"invalid", invalid,
+		"failures", len(response.BatchItemFailures),
+	)
+	return response, nil
+}
+
+// Decodifica e valida os eventos contidos em um registro do Kinesis.
+func (p *KinesisHandler) decodeRecord(ctx context.Context, record events.KinesisEventRecord) ([]*models.Event, error) {
+	ctx, span := p.tracer.Start(ctx, "decodeRecord",
+		trace.WithAttributes(
+			attribute.String("messaging.message.id", record.Kinesis.SequenceNumber),
+			attribute.String("messaging.destination.partition.id", record.Kinesis.PartitionKey),
+		),
+	)
+	defer span.End()
+	payloads, err := decodeKinesisData(record.Kinesis.Data)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to decode record data")
+		slog.ErrorContext(ctx, "unable to decode record data", "sequence_number", record.Kinesis.SequenceNumber, "error", err)
+		return nil, err
+	}
+	records := make([]*models.Event, 0, len(payloads))
+	for _, payload := range payloads {
+		decoded, err := decodeEvents(payload)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "unable to decode record data")
+			slog.ErrorContext(ctx, "unable to decode record data", "sequence_number", record.Kinesis.SequenceNumber, "error", err)
+			return nil, err
+		}
+		records = append(records, decoded...)
+	}
+	for i, event := range records {
+		if err := event.Validate(); err != nil {
+			span.AddEvent(
+				"record validation failed",
+				trace.WithAttributes(attribute.String("error", err.Error())),
+			)
+			slog.ErrorContext(ctx, "invalid record", "sequence_number", record.Kinesis.SequenceNumber, "error", err)
+			return nil, err
+		}
+		// o id é derivado do número de sequência para que o reprocessamento não duplique eventos
+		if event.Id == "" {
+			event.Id = uuid.NewSHA1(uuid.NameSpaceOID, fmt.Appendf(nil, "%s/%d", record.EventID, i)).String()
+		}
+	}
+	return records, nil
+}
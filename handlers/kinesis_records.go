@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	// cabeçalho dos registros agregados pela Kinesis Producer Library (KPL)
+	kplMagic = []byte{0xF3, 0x89, 0x9A, 0xC2}
+	// cabeçalho dos dados compactados com gzip
+	gzipMagic = []byte{0x1F, 0x8B}
+)
+
+// Decodifica os dados de um registro do Kinesis, descompactando gzip e
+// desagregando registros da KPL quando necessário.
+// Retorna os dados de cada registro de usuário contido no registro.
+func decodeKinesisData(data []byte) ([][]byte, error) {
+	data, err := gunzipIfNeeded(data)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, kplMagic) {
+		return [][]byte{data}, nil
+	}
+	records, err := deaggregateKPL(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, record := range records {
+		if records[i], err = gunzipIfNeeded(record); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// Descompacta os dados caso estejam no formato gzip.
+func gunzipIfNeeded(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read gzip data, %w", err)
+	}
+	defer reader.Close()
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read gzip data, %w", err)
+	}
+	return out, nil
+}
+
+// Desagrega um registro no formato da KPL:
+// cabeçalho (4 bytes) + AggregatedRecord (protobuf) + MD5 do protobuf (16 bytes).
+func deaggregateKPL(data []byte) ([][]byte, error) {
+	if len(data) < len(kplMagic)+md5.Size {
+		return nil, fmt.Errorf("aggregated record too short")
+	}
+	message := data[len(kplMagic) : len(data)-md5.Size]
+	checksum := md5.Sum(message)
+	if !bytes.Equal(checksum[:], data[len(data)-md5.Size:]) {
+		return nil, fmt.Errorf("aggregated record checksum mismatch")
+	}
+	// campo 3 do AggregatedRecord contém os registros de usuário
+	records := make([][]byte, 0)
+	err := walkProtobuf(message, func(number protowire.Number, value []byte) error {
+		if number != 3 {
+			return nil
+		}
+		// campo 3 do Record contém os dados do registro
+		var recordData []byte
+		err := walkProtobuf(value, func(number protowire.Number, value []byte) error {
+			if number == 3 {
+				recordData = value
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		records = append(records, recordData)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse aggregated record, %w", err)
+	}
+	return records, nil
+}
+
+// Percorre os campos de uma mensagem protobuf, chamando fn para os campos do tipo bytes.
+func walkProtobuf(message []byte, fn func(number protowire.Number, value []byte) error) error {
+	for len(message) > 0 {
+		number, wireType, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		message = message[n:]
+		if wireType == protowire.BytesType {
+			value, n := protowire.ConsumeBytes(message)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if err := fn(number, value); err != nil {
+				return err
+			}
+			message = message[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(number, wireType, message)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		message = message[n:]
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"fmt"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Monta um Record da KPL com o índice da chave de partição e os dados.
+func kplRecord(data []byte) []byte {
+	var record []byte
+	record = protowire.AppendTag(record, 1, protowire.VarintType)
+	record = protowire.AppendVarint(record, 0)
+	record = protowire.AppendTag(record, 3, protowire.BytesType)
+	return protowire.AppendBytes(record, data)
+}
+
+// Monta um AggregatedRecord com a tabela de chaves de partição e os registros.
+func kplMessage(records ...[]byte) []byte {
+	var message []byte
+	message = protowire.AppendTag(message, 1, protowire.BytesType)
+	message = protowire.AppendString(message, "partition")
+	for _, record := range records {
+		message = protowire.AppendTag(message, 3, protowire.BytesType)
+		message = protowire.AppendBytes(message, record)
+	}
+	return message
+}
+
+// Envolve a mensagem com o cabeçalho e o MD5 do formato da KPL.
+func kplAggregate(message []byte) []byte {
+	checksum := md5.Sum(message)
+	data := append(append([]byte{}, kplMagic...), message...)
+	return append(data, checksum[:]...)
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestDecodeKinesisData(t *testing.T) {
+	first, second := []byte(`{"id":"1"}`), []byte(`{"id":"2"}`)
+	corrupted := kplAggregate(kplMessage(kplRecord(first)))
+	corrupted[len(kplMagic)] ^= 0xFF
+	truncated := kplMessage(kplRecord(first))
+	truncated = truncated[:len(truncated)-1]
+	tests := []struct {
+		name    string
+		data    []byte
+		want    [][]byte
+		wantErr bool
+	}{
+		{name: "plain record", data: first, want: [][]byte{first}},
+		{name: "gzip record", data: gzipData(t, first), want: [][]byte{first}},
+		{name: "magic header only in the middle", data: append([]byte("x"), kplMagic...), want: [][]byte{append([]byte("x"), kplMagic...)}},
+		{name: "aggregated records", data: kplAggregate(kplMessage(kplRecord(first), kplRecord(second))), want: [][]byte{first, second}},
+		{name: "aggregated gzip records", data: kplAggregate(kplMessage(kplRecord(gzipData(t, first)), kplRecord(second))), want: [][]byte{first, second}},
+		{name: "gzip aggregated record", data: gzipData(t, kplAggregate(kplMessage(kplRecord(first)))), want: [][]byte{first}},
+		{name: "aggregated without records", data: kplAggregate(kplMessage()), want: [][]byte{}},
+		{name: "aggregated record too short", data: append(append([]byte{}, kplMagic...), 0x01), wantErr: true},
+		{name: "checksum mismatch", data: corrupted, wantErr: true},
+		{name: "truncated protobuf", data: kplAggregate(truncated), wantErr: true},
+		{name: "invalid gzip", data: append(append([]byte{}, gzipMagic...), 0x00), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := decodeKinesisData(test.data)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d records, want %d", len(got), len(test.want))
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], test.want[i]) {
+					t.Errorf("record %d = %q, want %q", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWalkProtobuf(t *testing.T) {
+	var message []byte
+	message = protowire.AppendTag(message, 1, protowire.VarintType)
+	message = protowire.AppendVarint(message, 300)
+	message = protowire.AppendTag(message, 2, protowire.Fixed64Type)
+	message = protowire.AppendFixed64(message, 7)
+	message = protowire.AppendTag(message, 3, protowire.BytesType)
+	message = protowire.AppendBytes(message, []byte("a"))
+	message = protowire.AppendTag(message, 4, protowire.Fixed32Type)
+	message = protowire.AppendFixed32(message, 7)
+	message = protowire.AppendTag(message, 5, protowire.BytesType)
+	message = protowire.AppendBytes(message, []byte("b"))
+	tests := []struct {
+		name    string
+		message []byte
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty message", message: nil},
+		{name: "skips non bytes fields", message: message, want: []string{"3=a", "5=b"}},
+		{name: "truncated tag", message: []byte{0x80}, wantErr: true},
+		{name: "truncated bytes", message: message[:len(message)-1], want: []string{"3=a"}, wantErr: true},
+		{name: "truncated varint", message: []byte{0x08, 0x80}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			err := walkProtobuf(test.message, func(number protowire.Number, value []byte) error {
+				got = append(got, fmt.Sprintf("%d=%s", number, value))
+				return nil
+			})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, test.wantErr)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got fields %q, want %q", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("field %d = %q, want %q", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
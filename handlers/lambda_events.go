@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Define o tipo de evento HTTP recebido pelo Lambda.
+type lambdaEventType int
+
+const (
+	// evento desconhecido
+	lambdaEventUnknown lambdaEventType = iota
+	// API Gateway REST (payload 1.0)
+	lambdaEventAPIGatewayV1
+	// API Gateway HTTP (payload 2.0)
+	lambdaEventAPIGatewayV2
+	// Application Load Balancer
+	lambdaEventALB
+	// Lambda Function URL
+	lambdaEventFunctionURL
+)
+
+// Retorna o nome do tipo de evento.
+func (t lambdaEventType) String() string {
+	switch t {
+	case lambdaEventAPIGatewayV1:
+		return "apigateway.v1"
+	case lambdaEventAPIGatewayV2:
+		return "apigateway.v2"
+	case lambdaEventALB:
+		return "alb"
+	case lambdaEventFunctionURL:
+		return "function.url"
+	default:
+		return "unknown"
+	}
+}
+
+// Estrutura mínima usada para identificar o tipo do evento recebido.
+type lambdaEventProbe struct {
+	Version        string `json:"version"`
+	HTTPMethod     string `json:"httpMethod"`
+	RequestContext struct {
+		ELB        json.RawMessage `json:"elb"`
+		HTTP       json.RawMessage `json:"http"`
+		DomainName string          `json:"domainName"`
+	} `json:"requestContext"`
+}
+
+// Representação normalizada de uma requisição HTTP recebida pelo Lambda,
+// independente do serviço que originou o evento.
+type lambdaRequest struct {
+	// tipo do evento original
+	eventType lambdaEventType
+	// método HTTP
+	method string
+	// caminho da requisição
+	path string
+	// prefixo do caminho externo antes das rotas da API, como o estágio do API Gateway
+	basePath string
+	// cabeçalhos com as chaves em minúsculo
+	headers http.Header
+	// parâmetros da query string
+	query url.Values
+	// parâmetros do caminho
+	pathParameters map[string]string
+	// corpo já decodificado
+	body string
+	// endereço de origem
+	sourceIP string
+	// agente do cliente
+	userAgent string
+	// indica se o cliente espera cabeçalhos com múltiplos valores na resposta
+	multiValueHeaders bool
+}
+
+// Representação normalizada de uma resposta HTTP do Lambda.
+type lambdaResponse struct {
+	// status code da resposta
+	StatusCode int
+	// cabeçalhos da resposta
+	Headers http.Header
+	// corpo da resposta
+	Body string
+}
+
+// Identifica o tipo do evento a partir do payload recebido.
+func detectLambdaEvent(payload []byte) (lambdaEventType, error) {
+	probe := &lambdaEventProbe{}
+	if err := json.Unmarshal(payload, probe); err != nil {
+		return lambdaEventUnknown, err
+	}
+	switch {
+	case len(probe.RequestContext.ELB) > 0:
+		return lambdaEventALB, nil
+	case len(probe.RequestContext.HTTP) > 0:
+		if strings.Contains(probe.RequestContext.DomainName, ".lambda-url.") {
+			return lambdaEventFunctionURL, nil
+		}
+		return lambdaEventAPIGatewayV2, nil
+	case probe.HTTPMethod != "":
+		return lambdaEventAPIGatewayV1, nil
+	}
+	return lambdaEventUnknown, fmt.Errorf("unsupported lambda event")
+}
+
+// Converte o payload recebido para a requisição normalizada.
+func parseLambdaRequest(payload []byte) (*lambdaRequest, error) {
+	eventType, err := detectLambdaEvent(payload)
+	if err != nil {
+		return nil, err
+	}
+	var request *lambdaRequest
+	var body string
+	var isBase64Encoded bool
+	switch eventType {
+	case lambdaEventAPIGatewayV1:
+		event := events.APIGatewayProxyRequest{}
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		request = &lambdaRequest{
+			method: event.HTTPMethod,
+			path:   event.Path,
+			// o caminho do contexto inclui o estágio ou o mapeamento do domínio personalizado
+			basePath:          apiBasePath(event.RequestContext.Path),
+			headers:           mergeHeaders(event.Headers, event.MultiValueHeaders),
+			query:             mergeValues(event.QueryStringParameters, event.MultiValueQueryStringParameters, false),
+			pathParameters:    event.PathParameters,
+			sourceIP:          event.RequestContext.Identity.SourceIP,
+			userAgent:         event.RequestContext.Identity.UserAgent,
+			multiValueHeaders: true,
+		}
+		body, isBase64Encoded = event.Body, event.IsBase64Encoded
+	case lambdaEventAPIGatewayV2:
+		event := events.APIGatewayV2HTTPRequest{}
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		request = &lambdaRequest{
+			method:         event.RequestContext.HTTP.Method,
+			path:           event.RequestContext.HTTP.Path,
+			headers:        mergeHeaders(event.Headers, nil),
+			query:          mergeValues(event.QueryStringParameters, nil, false),
+			pathParameters: event.PathParameters,
+			sourceIP:       event.RequestContext.HTTP.SourceIP,
+			userAgent:      event.RequestContext.HTTP.UserAgent,
+		}
+		body, isBase64Encoded = event.Body, event.IsBase64Encoded
+	case lambdaEventALB:
+		event := events.ALBTargetGroupRequest{}
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		// o ALB não decodifica os parâmetros da query string
+		request = &lambdaRequest{
+			method:            event.HTTPMethod,
+			path:              event.Path,
+			headers:           mergeHeaders(event.Headers, event.MultiValueHeaders),
+			query:             mergeValues(event.QueryStringParameters, event.MultiValueQueryStringParameters, true),
+			multiValueHeaders: event.MultiValueHeaders != nil,
+		}
+		request.sourceIP = strings.TrimSpace(strings.Split(request.headers.Get("X-Forwarded-For"), ",")[0])
+		request.userAgent = request.headers.Get("User-Agent")
+		body, isBase64Encoded = event.Body, event.IsBase64Encoded
+	case lambdaEventFunctionURL:
+		event := events.LambdaFunctionURLRequest{}
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		request = &lambdaRequest{
+			method:    event.RequestContext.HTTP.Method,
+			path:      event.RequestContext.HTTP.Path,
+			headers:   mergeHeaders(event.Headers, nil),
+			query:     mergeValues(event.QueryStringParameters, nil, false),
+			sourceIP:  event.RequestContext.HTTP.SourceIP,
+			userAgent: event.RequestContext.HTTP.UserAgent,
+		}
+		body, isBase64Encoded = event.Body, event.IsBase64Encoded
+	}
+	request.eventType = eventType
+	request.method = strings.ToUpper(request.method)
+	if eventType != lambdaEventAPIGatewayV1 {
+		request.basePath = apiBasePath(request.path)
+	}
+	if request.pathParameters == nil {
+		request.pathParameters = make(map[string]string)
+	}
+	// ALB e Function URL não possuem roteamento, então o id é obtido do caminho
+	if request.pathParameters["id"] == "" {
+		if id := eventIdFromPath(request.path); id != "" {
+			request.pathParameters["id"] = id
+		}
+	}
+	if isBase64Encoded {
+		data, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode base64 body, %w", err)
+		}
+		body = string(data)
+	}
+	request.body = body
+	return request, nil
+}
+
+// Extrai o id do evento de um caminho no formato /eventos/{id}.
+func eventIdFromPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] != "eventos" {
+			continue
+		}
+		if i+2 == len(segments) {
+			if id, err := url.PathUnescape(segments[i+1]); err == nil {
+				return id
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// Retorna o prefixo do caminho externo antes da primeira rota da API (eventos, errors
+// ou health), como o estágio do API Gateway, ou vazio se a API estiver na raiz.
+func apiBasePath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		switch segment {
+		case "eventos", "errors", "health":
+			if i == 0 {
+				return ""
+			}
+			return "/" + strings.Join(segments[:i], "/")
+		}
+	}
+	return ""
+}
+
+// Une os cabeçalhos simples e os de múltiplos valores.
+func mergeHeaders(single map[string]string, multi map[string][]string) http.Header {
+	headers := make(http.Header)
+	for k, values := range multi {
+		for _, v := range values {
+			headers.Add(k, v)
+		}
+	}
+	for k, v := range single {
+		if _, ok := headers[http.CanonicalHeaderKey(k)]; !ok {
+			headers.Set(k, v)
+		}
+	}
+	return headers
+}
+
+// Une os parâmetros simples e os de múltiplos valores da query string.
+func mergeValues(single map[string]string, multi map[string][]string, escaped bool) url.Values {
+	values := make(url.Values)
+	unescape := func(s string) string {
+		if !escaped {
+			return s
+		}
+		if v, err := url.QueryUnescape(s); err == nil {
+			return v
+		}
+		return s
+	}
+	for k, list := range multi {
+		for _, v := range list {
+			values.Add(unescape(k), unescape(v))
+		}
+	}
+	for k, v := range single {
+		if !values.Has(unescape(k)) {
+			values.Set(unescape(k), unescape(v))
+		}
+	}
+	return values
+}
+
+// Converte a resposta normalizada para o formato esperado pelo serviço que originou o evento.
+func (r *lambdaResponse) toEvent(request *lambdaRequest) interface{} {
+	body, isBase64Encoded := r.Body, false
+	if !utf8.ValidString(body) {
+		body, isBase64Encoded = base64.StdEncoding.EncodeToString([]byte(body)), true
+	}
+	var eventType lambdaEventType
+	multiValueHeaders := false
+	if request != nil {
+		eventType = request.eventType
+		multiValueHeaders = request.multiValueHeaders
+	}
+	switch eventType {
+	case lambdaEventAPIGatewayV1:
+		return events.APIGatewayProxyResponse{
+			StatusCode:        r.StatusCode,
+			Headers:           singleHeaders(r.Headers),
+			MultiValueHeaders: r.Headers,
+			Body:              body,
+			IsBase64Encoded:   isBase64Encoded,
+		}
+	case lambdaEventALB:
+		response := events.ALBTargetGroupResponse{
+			StatusCode:        r.StatusCode,
+			StatusDescription: fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+			Body:              body,
+			IsBase64Encoded:   isBase64Encoded,
+		}
+		// o ALB só aceita um dos formatos de acordo com a configuração do target group
+		if multiValueHeaders {
+			response.MultiValueHeaders = r.Headers
+		} else {
+			response.Headers = singleHeaders(r.Headers)
+		}
+		return response
+	case lambdaEventFunctionURL:
+		headers, cookies := cookieHeaders(r.Headers)
+		return events.LambdaFunctionURLResponse{
+			StatusCode:      r.StatusCode,
+			Headers:         headers,
+			Body:            body,
+			IsBase64Encoded: isBase64Encoded,
+			Cookies:         cookies,
+		}
+	default:
+		headers, cookies := cookieHeaders(r.Headers)
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode:      r.StatusCode,
+			Headers:         headers,
+			Body:            body,
+			IsBase64Encoded: isBase64Encoded,
+			Cookies:         cookies,
+		}
+	}
+}
+
+// Converte os cabeçalhos para o formato de valor único, unindo os valores por vírgula.
+func singleHeaders(headers http.Header) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	single := make(map[string]string, len(headers))
+	for k, v := range headers {
+		single[k] = strings.Join(v, ",")
+	}
+	return single
+}
+
+// Separa os cookies dos demais cabeçalhos, pois o payload 2.0 os trata em campo próprio.
+func cookieHeaders(headers http.Header) (map[string]string, []string) {
+	cookies := headers.Values("Set-Cookie")
+	clone := headers.Clone()
+	clone.Del("Set-Cookie")
+	return singleHeaders(clone), cookies
+}
@@ -3,20 +3,23 @@ package handlers
 import (
 	"api/interfaces"
 	"api/models"
+	"api/repositories"
+	"api/telemetry"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -24,6 +27,8 @@ import (
 type LambdaHandlerConfig struct {
 	// repositório de dados
 	Repository interfaces.Repository
+	// URL absoluta onde a documentação dos erros é servida, vazio usa o caminho da requisição
+	ErrorBaseURL string
 }
 
 // Estrutura do LambdaHandler.
@@ -32,24 +37,95 @@ type LambdaHandler struct {
 	config *LambdaHandlerConfig
 	// configura o tracer
 	tracer trace.Tracer
+	// metricas de requisições, as mesmas do modo servidor HTTP
+	requestCounter   metric.Int64Counter
+	requestHistogram metric.Float64Histogram
+	// metricas do ambiente de execução do Lambda
+	coldStartCounter   metric.Int64Counter
+	initHistogram      metric.Float64Histogram
+	remainingHistogram metric.Float64Histogram
 }
 
 // Cria uma nova instância do LambdaHandler.
 func NewLambdaHandler(config *LambdaHandlerConfig) *LambdaHandler {
-	return &LambdaHandler{
+	h := &LambdaHandler{
 		config: config,
 		tracer: otel.Tracer("lambda.handler"),
 	}
+	// configura as metricas
+	meter := otel.Meter("http.server.metrics")
+	if counter, err := meter.Int64Counter("custom.http.requests.total",
+		metric.WithDescription("The number of HTTP requests executed"),
+		metric.WithUnit("{requests}")); err == nil {
+		h.requestCounter = counter
+	} else {
+		panic(err)
+	}
+	if histogram, err := meter.Float64Histogram("custom.http.requests.duration",
+		metric.WithDescription("The duration of HTTP requests"),
+		metric.WithUnit("ms")); err == nil {
+		h.requestHistogram = histogram
+	} else {
+		panic(err)
+	}
+	meter = otel.Meter("lambda.metrics")
+	if counter, err := meter.Int64Counter("custom.lambda.coldstarts.total",
+		metric.WithDescription("The number of Lambda cold starts"),
+		metric.WithUnit("{coldstarts}")); err == nil {
+		h.coldStartCounter = counter
+	} else {
+		panic(err)
+	}
+	if histogram, err := meter.Float64Histogram("custom.lambda.init.duration",
+		metric.WithDescription("The time from process start to the first invocation"),
+		metric.WithUnit("ms")); err == nil {
+		h.initHistogram = histogram
+	} else {
+		panic(err)
+	}
+	if histogram, err := meter.Float64Histogram("custom.lambda.remaining_time",
+		metric.WithDescription("The time left before the invocation deadline when the handler returns"),
+		metric.WithUnit("ms")); err == nil {
+		h.remainingHistogram = histogram
+	} else {
+		panic(err)
+	}
+	return h
 }
 
-// Identifica o método HTTP da requisição e direciona para o handler apropriado.
-func (p *LambdaHandler) HandleRequest(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
-	ctx, span := p.tracer.Start(ctx, "HandleRequest", trace.WithSpanKind(trace.SpanKindServer))
-	defer span.End()
+// Identifica o tipo do evento e o método HTTP da requisição e direciona para o handler apropriado.
+// Suporta eventos do API Gateway REST (v1) e HTTP (v2), ALB e Lambda Function URL,
+// respondendo no formato correspondente ao evento recebido.
+func (p *LambdaHandler) HandleRequest(ctx context.Context, payload json.RawMessage) (interface{}, error) {
 	start := time.Now()
-	switch request.RequestContext.HTTP.Method {
+	p.recordColdStart(ctx, start)
+	defer p.recordRemainingTime(ctx)
+	request, err := parseLambdaRequest(payload)
+	ctx, span := p.startSpan(ctx, request)
+	defer span.End()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to parse lambda event")
+		slog.ErrorContext(ctx, "unable to parse lambda event", "error", err)
+		// o evento inválido é respondido no formato detectado, ou no do API Gateway HTTP,
+		// e os erros do Go ficam reservados às falhas de infraestrutura
+		eventType, _ := detectLambdaEvent(payload)
+		request = &lambdaRequest{eventType: eventType}
+		response, err := p.problem(ctx, request, ErrorInvalidRequest, err.Error())
+		p.recordRequest(ctx, "_OTHER", "unknown", response.StatusCode, time.Since(start))
+		return response.toEvent(request), err
+	}
+	span.SetAttributes(attribute.String("faas.trigger.source", request.eventType.String()))
+	var response lambdaResponse
+	switch request.method {
 	case "GET":
-		response, err = p.handleGet(ctx, request)
+		if code, ok := errorTypeCode(request.path); ok && request.pathParameters["id"] == "" {
+			response, err = p.handleErrorType(ctx, request, code)
+		} else if request.pathParameters["id"] == "" {
+			response, err = p.handleFind(ctx, request)
+		} else {
+			response, err = p.handleGet(ctx, request)
+		}
 	case "POST":
 		response, err = p.handlePost(ctx, request)
 	case "PUT":
@@ -57,216 +133,225 @@ func (p *LambdaHandler) HandleRequest(ctx context.Context, request events.APIGat
 	case "DELETE":
 		response, err = p.handleDelete(ctx, request)
 	default:
-		response, err = events.APIGatewayV2HTTPResponse{
-			StatusCode: 405,
-			Body:       "Method Not Allowed",
-		}, nil
+		response, err = p.problem(ctx, request, ErrorMethodNotAllowed, request.method)
 	}
 	duration := time.Since(start)
+	route := lambdaRoute(request)
+	span.SetName(strings.ToUpper(request.method) + " " + route)
+	span.SetAttributes(telemetry.HTTPServerAttributes(request.method, route, response.StatusCode)...)
+	p.recordRequest(ctx, request.method, route, response.StatusCode, duration)
 	slog.InfoContext(
 		ctx,
-		fmt.Sprintf("request duration {%dms} status code {%d} method {%s} path {%s} remote address {%s} agent {%s}",
-			duration.Milliseconds(),
-			response.StatusCode,
-			request.RequestContext.HTTP.Method,
-			request.RequestContext.HTTP.Path,
-			request.RequestContext.HTTP.SourceIP,
-			request.RequestContext.HTTP.UserAgent,
-		),
+		"request",
+		"duration_ms", duration.Milliseconds(),
+		"status_code", response.StatusCode,
+		"method", request.method,
+		"path", request.path,
+		"remote_address", request.sourceIP,
+		"user_agent", request.userAgent,
 	)
-	return response, err
+	return response.toEvent(request), err
+}
+
+// Retorna a rota equivalente à do modo servidor HTTP.
+func lambdaRoute(request *lambdaRequest) string {
+	if request.pathParameters["id"] != "" {
+		return "/eventos/{id}"
+	}
+	if code, ok := errorTypeCode(request.path); ok {
+		if code == "" {
+			return "/errors"
+		}
+		return "/errors/{code}"
+	}
+	return "/eventos"
+}
+
+// Registra as metricas da requisição com os mesmos atributos do modo servidor HTTP.
+func (p *LambdaHandler) recordRequest(ctx context.Context, method string, route string, statusCode int, duration time.Duration) {
+	attrs := metric.WithAttributes(telemetry.HTTPServerAttributes(method, route, statusCode)...)
+	p.requestCounter.Add(ctx, 1, attrs)
+	p.requestHistogram.Record(ctx, float64(duration.Milliseconds()), attrs)
+}
+
+// Registra o cold start e o tempo de inicialização na primeira invocação do ambiente de execução.
+// Deve ser chamado antes do início do span, que marca o ambiente como já utilizado.
+func (p *LambdaHandler) recordColdStart(ctx context.Context, start time.Time) {
+	if lambdaWarm.Load() {
+		return
+	}
+	p.coldStartCounter.Add(ctx, 1)
+	p.initHistogram.Record(ctx, float64(start.Sub(processStart).Milliseconds()))
+}
+
+// Registra o tempo restante até o limite da invocação ao final do processamento.
+func (p *LambdaHandler) recordRemainingTime(ctx context.Context) {
+	if deadline, ok := ctx.Deadline(); ok {
+		p.remainingHistogram.Record(ctx, float64(time.Until(deadline).Milliseconds()))
+	}
+}
+
+// Inicia o span da invocação como continuação do rastreamento do chamador.
+// O contexto é extraído dos cabeçalhos da requisição (traceparent, tracestate e baggage)
+// com o propagador configurado; o contexto do X-Ray da invocação é adicionado como link
+// ou usado como pai quando os cabeçalhos não trazem um contexto válido.
+func (p *LambdaHandler) startSpan(ctx context.Context, request *lambdaRequest) (context.Context, trace.Span) {
+	options := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(lambdaInvocationAttributes(ctx)...),
+		trace.WithAttributes(attribute.String("faas.trigger", "http")),
+	}
+	parent := ctx
+	if request != nil {
+		parent = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(request.headers))
+	}
+	if xray, ok := xrayTraceContext(ctx); ok {
+		if trace.SpanContextFromContext(parent).IsValid() {
+			options = append(options, trace.WithLinks(trace.Link{
+				SpanContext: xray,
+				Attributes:  []attribute.KeyValue{attribute.String("link.source", "aws.xray")},
+			}))
+		} else {
+			parent = trace.ContextWithRemoteSpanContext(parent, xray)
+		}
+	}
+	return p.tracer.Start(parent, "HandleRequest", options...)
 }
 
 // Processa requisições GET.
-func (p *LambdaHandler) handleGet(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+func (p *LambdaHandler) handleGet(ctx context.Context, request *lambdaRequest) (response lambdaResponse, err error) {
 	ctx, span := p.tracer.Start(ctx, "handleGet")
 	defer span.End()
-	id := request.PathParameters["id"]
+	id := request.pathParameters["id"]
 	if id == "" {
 		span.AddEvent("{id} not provided")
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Bad Request",
-			Status:   http.StatusBadRequest,
-			Detail:   "Missing event ID in URL",
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusBadRequest)
+		return p.problem(ctx, request, ErrorMissingEventID)
 	}
 	event, err := p.config.Repository.Get(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		span.AddEvent("record not found")
+		return p.repositoryError(ctx, request, err)
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to get record from repository")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to get record from repository, %s", err))
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Internal Server Error",
-			Status:   http.StatusInternalServerError,
-			Detail:   err.Error(),
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusInternalServerError)
-	}
-	if event == nil {
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Not Found",
-			Status:   http.StatusNotFound,
-			Detail:   "Event not found",
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusNotFound)
+		slog.ErrorContext(ctx, "unable to get record from repository", "error", err)
+		return p.repositoryError(ctx, request, err)
 	}
 	return p.toJson(ctx, event, http.StatusOK)
 }
 
 // Processa requisições POST.
-func (p *LambdaHandler) handlePost(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+func (p *LambdaHandler) handlePost(ctx context.Context, request *lambdaRequest) (response lambdaResponse, err error) {
 	ctx, span := p.tracer.Start(ctx, "handlePost")
 	defer span.End()
 	event := &models.Event{}
-	if err := json.NewDecoder(strings.NewReader(request.Body)).Decode(event); err != nil {
+	if err := json.NewDecoder(strings.NewReader(request.body)).Decode(event); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to decode json")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to decode json, %s", err))
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "https://www.rfc-editor.org/rfc/rfc8259",
-			Title:    "Invalid JSON",
-			Status:   http.StatusBadRequest,
-			Detail:   err.Error(),
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusBadRequest)
+		slog.ErrorContext(ctx, "unable to decode json", "error", err)
+		return p.problem(ctx, request, ErrorInvalidJSON, err.Error())
 	}
 	if err = event.Validate(); err != nil {
 		span.AddEvent(
 			"record validation failed",
 			trace.WithAttributes(attribute.String("error", err.Error())),
 		)
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Invalid Body",
-			Status:   http.StatusBadRequest,
-			Detail:   err.Error(),
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusBadRequest)
+		return p.problem(ctx, request, ErrorInvalidEvent, err.Error())
 	}
 	event.Id = uuid.New().String()
 	err = p.config.Repository.Save(ctx, event)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to save record in repository")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to save record in repository, %s", err))
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Internal Server Error",
-			Status:   http.StatusInternalServerError,
-			Detail:   err.Error(),
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "unable to save record in repository", "error", err)
+		return p.repositoryError(ctx, request, err)
 	}
 	return p.toJson(ctx, event, http.StatusCreated)
 }
 
 // Processa requisições PUT.
-func (p *LambdaHandler) handlePut(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+func (p *LambdaHandler) handlePut(ctx context.Context, request *lambdaRequest) (response lambdaResponse, err error) {
 	ctx, span := p.tracer.Start(ctx, "handlePut")
 	defer span.End()
-	id := request.PathParameters["id"]
+	id := request.pathParameters["id"]
 	if id == "" {
 		span.AddEvent("{id} not provided")
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Bad Request",
-			Status:   http.StatusBadRequest,
-			Detail:   "Missing event ID in URL",
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusBadRequest)
+		return p.problem(ctx, request, ErrorMissingEventID)
 	}
 	event := &models.Event{}
-	if err := json.NewDecoder(strings.NewReader(request.Body)).Decode(event); err != nil {
+	if err := json.NewDecoder(strings.NewReader(request.body)).Decode(event); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to decode json")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to decode json, %s", err))
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "https://www.rfc-editor.org/rfc/rfc8259",
-			Title:    "Invalid JSON",
-			Status:   http.StatusBadRequest,
-			Detail:   err.Error(),
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusBadRequest)
+		slog.ErrorContext(ctx, "unable to decode json", "error", err)
+		return p.problem(ctx, request, ErrorInvalidJSON, err.Error())
 	}
 	if err = event.Validate(); err != nil {
 		span.AddEvent(
 			"record validation failed",
 			trace.WithAttributes(attribute.String("error", err.Error())),
 		)
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Invalid Body",
-			Status:   http.StatusBadRequest,
-			Detail:   err.Error(),
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusBadRequest)
+		return p.problem(ctx, request, ErrorInvalidEvent, err.Error())
 	}
 	event.Id = id
 	err = p.config.Repository.Save(ctx, event)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to save record in repository")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to save record in repository, %s", err))
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Internal Server Error",
-			Status:   http.StatusInternalServerError,
-			Detail:   err.Error(),
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "unable to save record in repository", "error", err)
+		return p.repositoryError(ctx, request, err)
 	}
 	return p.toJson(ctx, event, http.StatusCreated)
 }
 
 // Processa requisições DELETE.
-func (p *LambdaHandler) handleDelete(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+func (p *LambdaHandler) handleDelete(ctx context.Context, request *lambdaRequest) (response lambdaResponse, err error) {
 	ctx, span := p.tracer.Start(ctx, "handleDelete")
 	defer span.End()
-	id := request.PathParameters["id"]
+	id := request.pathParameters["id"]
 	if id == "" {
 		span.AddEvent("{id} not provided")
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Bad Request",
-			Status:   http.StatusBadRequest,
-			Detail:   "Missing event ID in URL",
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusBadRequest)
-	}
-	event, err := p.config.Repository.Delete(ctx, id)
+		return p.problem(ctx, request, ErrorMissingEventID)
+	}
+	_, err = p.config.Repository.Delete(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		span.AddEvent("record not found")
+		return p.repositoryError(ctx, request, err)
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to delete record from repository")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to delete record from repository, %s", err))
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Internal Server Error",
-			Status:   http.StatusInternalServerError,
-			Detail:   err.Error(),
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusInternalServerError)
-	}
-	if event == nil {
-		span.AddEvent("record not found")
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Not Found",
-			Status:   http.StatusNotFound,
-			Detail:   "Event not found",
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusNotFound)
-	}
-	return events.APIGatewayV2HTTPResponse{
+		slog.ErrorContext(ctx, "unable to delete record from repository", "error", err)
+		return p.repositoryError(ctx, request, err)
+	}
+	return lambdaResponse{
 		StatusCode: http.StatusNoContent,
 	}, nil
 }
 
+// Retorna a documentação dos erros da API, ou do erro informado, no idioma do cabeçalho Accept-Language.
+func (p *LambdaHandler) handleErrorType(ctx context.Context, request *lambdaRequest, code string) (lambdaResponse, error) {
+	ctx, span := p.tracer.Start(ctx, "handleErrorType")
+	defer span.End()
+	language := negotiateLanguage(request.headers.Get("Accept-Language"))
+	var body any = errorTypes(language, p.errorBase(request))
+	if code != "" {
+		response, ok := errorType(ErrorCode(code), language, p.errorBase(request))
+		if !ok {
+			span.AddEvent("error type not found")
+			return p.problem(ctx, request, ErrorErrorTypeNotFound, code)
+		}
+		body = response
+	}
+	result, err := p.toJson(ctx, body, http.StatusOK)
+	if err == nil {
+		result.Headers.Set("Content-Language", language)
+	}
+	return result, err
+}
+
 // Processa requisições GET com filtro.
-func (p *LambdaHandler) handleFind(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+func (p *LambdaHandler) handleFind(ctx context.Context, request *lambdaRequest) (response lambdaResponse, err error) {
 	ctx, span := p.tracer.Start(ctx, "handleFind")
 	defer span.End()
 	// configura valores default caso sejam informados
@@ -275,86 +360,67 @@ func (p *LambdaHandler) handleFind(ctx context.Context, request events.APIGatewa
 	statusCode := 0
 	// trata os valores informados atualizando o default
 	// se necessário
-	if v := strings.TrimSpace(request.QueryStringParameters["from"]); v != "" {
+	if v := strings.TrimSpace(request.query.Get("from")); v != "" {
 		from, err = time.Parse(time.RFC3339, v)
 		if err != nil {
 			span.AddEvent(
 				"unable to parse value of {from} to RFC3339",
 				trace.WithAttributes(attribute.String("error", err.Error())),
 			)
-			return p.toJson(ctx, models.ErrorResponse{
-				Type:     "about:blank",
-				Title:    "Invalid Body",
-				Status:   http.StatusBadRequest,
-				Detail:   fmt.Sprintf("parameter {from} invalid, %s", err.Error()),
-				Instance: request.RequestContext.HTTP.Path,
-			}, http.StatusBadRequest)
+			return p.problem(ctx, request, ErrorInvalidParameter, "from", err.Error())
 		}
 	}
-	if v := strings.TrimSpace(request.QueryStringParameters["to"]); v != "" {
+	if v := strings.TrimSpace(request.query.Get("to")); v != "" {
 		to, err = time.Parse(time.RFC3339, v)
 		if err != nil {
 			span.AddEvent(
 				"unable to parse value of {to} to RFC3339",
 				trace.WithAttributes(attribute.String("error", err.Error())),
 			)
-			return p.toJson(ctx, models.ErrorResponse{
-				Type:     "about:blank",
-				Title:    "Invalid Body",
-				Status:   http.StatusBadRequest,
-				Detail:   fmt.Sprintf("parameter {to} invalid, %s", err.Error()),
-				Instance: request.RequestContext.HTTP.Path,
-			}, http.StatusBadRequest)
+			return p.problem(ctx, request, ErrorInvalidParameter, "to", err.Error())
 		}
 	}
-	if v := strings.TrimSpace(request.QueryStringParameters["statusCode"]); v != "" {
+	if v := strings.TrimSpace(request.query.Get("statusCode")); v != "" {
 		statusCode, err = strconv.Atoi(v)
 		if err != nil {
 			span.AddEvent(
 				"unable to parse value of {statusCode} to interger",
 				trace.WithAttributes(attribute.String("error", err.Error())),
 			)
-			return p.toJson(ctx, models.ErrorResponse{
-				Type:     "about:blank",
-				Title:    "Invalid Body",
-				Status:   http.StatusBadRequest,
-				Detail:   fmt.Sprintf("parameter {statusCode} invalid, %s", err.Error()),
-				Instance: request.RequestContext.HTTP.Path,
-			}, http.StatusBadRequest)
+			return p.problem(ctx, request, ErrorInvalidParameter, "statusCode", err.Error())
 		}
 	}
+	if from.After(to) {
+		span.AddEvent("{from} is after {to}")
+		return p.problem(ctx, request, ErrorInvalidDateRange)
+	}
 	events, err := p.config.Repository.FindByDateAndReturnCode(ctx, from, to, statusCode)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to find record in repository")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to find record in repository, %s", err))
-		return p.toJson(ctx, models.ErrorResponse{
-			Type:     "about:blank",
-			Title:    "Internal Server Error",
-			Status:   http.StatusInternalServerError,
-			Detail:   err.Error(),
-			Instance: request.RequestContext.HTTP.Path,
-		}, http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "unable to find record in repository", "error", err)
+		return p.repositoryError(ctx, request, err)
 	}
 	return p.toJson(ctx, events, http.StatusOK)
 }
 
 // Converte o objeto para JSON e escreve na resposta HTTP.
-func (p *LambdaHandler) toJson(ctx context.Context, object interface{}, statusCode int) (response events.APIGatewayV2HTTPResponse, err error) {
+func (p *LambdaHandler) toJson(ctx context.Context, object interface{}, statusCode int) (response lambdaResponse, err error) {
 	ctx, span := p.tracer.Start(ctx, "toJson")
 	defer span.End()
 	data, err := json.Marshal(object)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to marshal object to json")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to marshal object to json, %s", err))
-		return events.APIGatewayV2HTTPResponse{
+		slog.ErrorContext(ctx, "unable to marshal object to json", "error", err)
+		return lambdaResponse{
 			StatusCode: 500,
 			Body:       "Internal Server Error",
 		}, err
 	}
-	return events.APIGatewayV2HTTPResponse{
+	return lambdaResponse{
 		StatusCode: statusCode,
+		Headers:    http.Header{"Content-Type": []string{"application/json"}},
 		Body:       string(data),
 	}, nil
 }
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// indica que o ambiente de execução já processou uma invocação
+var lambdaWarm atomic.Bool
+
+// início aproximado do processo, usado para medir a inicialização do ambiente de execução
+var processStart = time.Now()
+
+// Retorna os atributos FaaS da invocação atual.
+// A primeira invocação do ambiente de execução é marcada como cold start.
+func lambdaInvocationAttributes(ctx context.Context) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.Bool("faas.coldstart", !lambdaWarm.Swap(true)),
+		attribute.String("cloud.provider", "aws"),
+	}
+	if lambdacontext.FunctionName != "" {
+		attrs = append(attrs, attribute.String("faas.name", lambdacontext.FunctionName))
+	}
+	if lambdacontext.FunctionVersion != "" {
+		attrs = append(attrs, attribute.String("faas.version", lambdacontext.FunctionVersion))
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		attrs = append(attrs, attribute.String("faas.invocation_id", lc.AwsRequestID))
+		if lc.InvokedFunctionArn != "" {
+			attrs = append(attrs, attribute.String("cloud.resource_id", lc.InvokedFunctionArn))
+		}
+	}
+	return attrs
+}
+
+// Retorna o contexto de rastreamento do AWS X-Ray da invocação,
+// informado no cabeçalho repassado pelo runtime (_X_AMZN_TRACE_ID).
+func xrayTraceContext(ctx context.Context) (trace.SpanContext, bool) {
+	header, _ := ctx.Value("x-amzn-trace-id").(string)
+	if header == "" {
+		header = os.Getenv("_X_AMZN_TRACE_ID")
+	}
+	return parseXRayTraceHeader(header)
+}
+
+// Converte o cabeçalho do X-Ray no formato Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1.
+func parseXRayTraceHeader(header string) (trace.SpanContext, bool) {
+	config := trace.SpanContextConfig{Remote: true}
+	for _, part := range strings.Split(header, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "Root":
+			// o id do X-Ray é composto pela versão, a época em hexadecimal e o identificador único
+			fields := strings.Split(value, "-")
+			if len(fields) != 3 || fields[0] != "1" {
+				return trace.SpanContext{}, false
+			}
+			data, err := hex.DecodeString(fields[1] + fields[2])
+			if err != nil || len(data) != len(config.TraceID) {
+				return trace.SpanContext{}, false
+			}
+			copy(config.TraceID[:], data)
+		case "Parent":
+			data, err := hex.DecodeString(value)
+			if err != nil || len(data) != len(config.SpanID) {
+				return trace.SpanContext{}, false
+			}
+			copy(config.SpanID[:], data)
+		case "Sampled":
+			if value == "1" {
+				config.TraceFlags = trace.FlagsSampled
+			}
+		}
+	}
+	spanContext := trace.NewSpanContext(config)
+	return spanContext, spanContext.IsValid()
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"api/repositories"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Converte o erro do repositório no código do catálogo, sem expor a mensagem
+// original, retornando também o tempo sugerido para o cabeçalho Retry-After.
+func repositoryErrorCode(err error) (ErrorCode, time.Duration) {
+	code := ErrorInternal
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		code = ErrorEventNotFound
+	case errors.Is(err, repositories.ErrConflict):
+		code = ErrorEventConflict
+	case errors.Is(err, repositories.ErrValidation):
+		code = ErrorEventRejected
+	case errors.Is(err, repositories.ErrThrottled):
+		code = ErrorRepositoryThrottled
+	case errors.Is(err, repositories.ErrCircuitOpen):
+		code = ErrorRepositoryCircuitOpen
+	// o tempo esgotado do chamador também impede a resposta do repositório
+	case errors.Is(err, repositories.ErrUnavailable), errors.Is(err, context.DeadlineExceeded):
+		code = ErrorRepositoryUnavailable
+	}
+	return code, repositories.RetryAfter(err)
+}
+
+// Formata o tempo sugerido em segundos inteiros para o cabeçalho Retry-After.
+func retryAfterHeader(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+}
+
+// Escreve a resposta do erro do repositório, com o cabeçalho Retry-After quando houver.
+func (p *HttpHandler) repositoryError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	code, retryAfter := repositoryErrorCode(err)
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", retryAfterHeader(retryAfter))
+	}
+	p.problem(ctx, w, r, code)
+}
+
+// Retorna a resposta do erro do repositório, com o cabeçalho Retry-After quando houver.
+func (p *LambdaHandler) repositoryError(ctx context.Context, request *lambdaRequest, err error) (lambdaResponse, error) {
+	code, retryAfter := repositoryErrorCode(err)
+	result, err := p.problem(ctx, request, code)
+	if err == nil && retryAfter > 0 {
+		result.Headers.Set("Retry-After", retryAfterHeader(retryAfter))
+	}
+	return result, err
+}
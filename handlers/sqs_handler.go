@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"api/interfaces"
+	"api/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Configuração do SqsHandler.
+type SqsHandlerConfig struct {
+	// repositório de dados
+	Repository interfaces.Repository
+}
+
+// Estrutura do SqsHandler.
+type SqsHandler struct {
+	// configuração do handler
+	config *SqsHandlerConfig
+	// configura o tracer
+	tracer trace.Tracer
+	// metricas de mensagens
+	messageCounter   metric.Int64Counter
+	messageHistogram metric.Float64Histogram
+}
+
+// Cria uma nova instância do SqsHandler.
+func NewSqsHandler(config *SqsHandlerConfig) *SqsHandler {
+	h := &SqsHandler{
+		config: config,
+		tracer: otel.Tracer("sqs.handler"),
+	}
+	// configura as metricas
+	meter := otel.Meter("sqs.consumer.metrics")
+	if counter, err := meter.Int64Counter("custom.sqs.messages.total",
+		metric.WithDescription("The number of SQS messages processed"),
+		metric.WithUnit("{messages}")); err == nil {
+		h.messageCounter = counter
+	} else {
+		panic(err)
+	}
+	if histogram, err := meter.Float64Histogram("custom.sqs.messages.duration",
+		metric.WithDescription("The duration of SQS message processing"),
+		metric.WithUnit("ms")); err == nil {
+		h.messageHistogram = histogram
+	} else {
+		panic(err)
+	}
+	return h
+}
+
+// Processa o lote de mensagens recebido do SQS.
+// As mensagens inválidas ou que falharam são reportadas em BatchItemFailures
+// para que apenas elas sejam reprocessadas.
+func (p *SqsHandler) HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) (response events.SQSEventResponse, err error) {
+	ctx, span := p.tracer.Start(ctx, "HandleRequest",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(lambdaInvocationAttributes(ctx)...),
+		trace.WithAttributes(
+			attribute.String("faas.trigger", "pubsub"),
+			attribute.String("messaging.system", "aws_sqs"),
+			attribute.Int("messaging.batch.message_count", len(sqsEvent.Records)),
+		),
+	)
+	defer span.End()
+	start := time.Now()
+	for _, message := range sqsEvent.Records {
+		if err := p.handleMessage(ctx, message); err != nil {
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: message.MessageId,
+			})
+		}
+	}
+	if len(response.BatchItemFailures) > 0 {
+		span.SetStatus(codes.Error, "some messages failed")
+	}
+	span.SetAttributes(attribute.Int("messaging.batch.failure_count", len(response.BatchItemFailures)))
+	duration := time.Since(start)
+	slog.InfoContext(
+		ctx,
+		"batch processed",
+		"duration_ms", duration.Milliseconds(),
+		"messages", len(sqsEvent.Records),
+		"failures", len(response.BatchItemFailures),
+	)
+	return response, nil
+}
+
+// Processa uma mensagem do SQS, salvando os eventos contidos no corpo.
+func (p *SqsHandler) handleMessage(ctx context.Context, message events.SQSMessage) (err error) {
+	start := time.Now()
+	// o span da mensagem continua o trace do produtor e é ligado ao span do lote
+	batchSpan := trace.SpanFromContext(ctx)
+	producerCtx := otel.GetTextMapPropagator().Extract(ctx, sqsMessageCarrier(message.MessageAttributes))
+	ctx, span := p.tracer.Start(producerCtx, "handleMessage",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(trace.Link{SpanContext: batchSpan.SpanContext()}),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "aws_sqs"),
+			attribute.String("messaging.message.id", message.MessageId),
+			attribute.String("messaging.source.name", message.EventSourceARN),
+		),
+	)
+	defer span.End()
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		attrs := []attribute.KeyValue{
+			attribute.String("messaging.system", "aws_sqs"),
+			attribute.String("result", result),
+		}
+		p.messageCounter.Add(ctx, 1, metric.WithAttributes(attrs...))
+		p.messageHistogram.Record(ctx, float64(time.Since(start).Milliseconds()), metric.WithAttributes(attrs...))
+	}()
+	records, err := decodeEvents([]byte(message.Body))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to decode message body")
+		slog.ErrorContext(ctx, "unable to decode message body", "message_id", message.MessageId, "error", err)
+		return err
+	}
+	for _, event := range records {
+		if err = event.Validate(); err != nil {
+			span.AddEvent(
+				"record validation failed",
+				trace.WithAttributes(attribute.String("error", err.Error())),
+			)
+			slog.ErrorContext(ctx, "invalid record on message", "message_id", message.MessageId, "error", err)
+			return err
+		}
+	}
+	for i, event := range records {
+		// o id é derivado do id da mensagem para que a reentrega não duplique eventos
+		if event.Id == "" {
+			event.Id = uuid.NewSHA1(uuid.NameSpaceOID, fmt.Appendf(nil, "%s/%d", message.MessageId, i)).String()
+		}
+		if err = p.config.Repository.Save(ctx, event); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "unable to save record in repository")
+			slog.ErrorContext(ctx, "unable to save record of message in repository", "message_id", message.MessageId, "error", err)
+			return err
+		}
+	}
+	span.SetAttributes(attribute.Int("messaging.message.record_count", len(records)))
+	return nil
+}
+
+// Decodifica um único evento ou uma lista de eventos em JSON.
+func decodeEvents(data []byte) ([]*models.Event, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+	if data[0] == '[' {
+		records := make([]*models.Event, 0)
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, err
+		}
+		for _, event := range records {
+			if event == nil {
+				return nil, fmt.Errorf("null record")
+			}
+		}
+		return records, nil
+	}
+	event := &models.Event{}
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, err
+	}
+	return []*models.Event{event}, nil
+}
+
+// Adapta os atributos da mensagem do SQS para extração do contexto de rastreamento.
+type sqsMessageCarrier map[string]events.SQSMessageAttribute
+
+// Retorna o valor do atributo.
+func (c sqsMessageCarrier) Get(key string) string {
+	for k, v := range c {
+		if strings.EqualFold(k, key) && v.StringValue != nil {
+			return *v.StringValue
+		}
+	}
+	return ""
+}
+
+// Não é utilizado, pois as mensagens são apenas consumidas.
+func (c sqsMessageCarrier) Set(key string, value string) {}
+
+// Retorna as chaves dos atributos.
+func (c sqsMessageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
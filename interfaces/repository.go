@@ -7,10 +7,18 @@ import (
 )
 
 // Define a interface do repositório.
+// Os erros podem ser comparados com errors.Is aos erros Err* do pacote repositories;
+// Get e Delete retornam ErrNotFound se o registro não existir.
 type Repository interface {
 	Create(ctx context.Context) error
 	Save(ctx context.Context, event *models.Event) error
+	SaveBatch(ctx context.Context, events []*models.Event) (failed []*models.Event, err error)
 	Delete(ctx context.Context, id string) (*models.Event, error)
 	Get(ctx context.Context, id string) (*models.Event, error)
 	FindByDateAndReturnCode(ctx context.Context, from time.Time, to time.Time, statusCode int) ([]*models.Event, error)
+	StreamByDate(ctx context.Context, from time.Time, to time.Time, statusCode *int, fn func(events []*models.Event) error) error
+	Scan(ctx context.Context, segments int, fn func(event *models.Event) error) error
+	SetTTL(ttl time.Duration)
+	Ping(ctx context.Context) error
+	Close(ctx context.Context) error
 }
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// log em JSON, um objeto por linha
+	LogFormatJSON = "json"
+	// log em texto no formato chave=valor
+	LogFormatText = "text"
+	// log na saída padrão desabilitado
+	LogFormatNone = "none"
+)
+
+// nível de log da aplicação, alterado pela configuração sem reiniciar a aplicação
+var logLevel = new(slog.LevelVar)
+
+// Handler que descarta os registros abaixo do nível configurado.
+type levelHandler struct {
+	level   slog.Leveler
+	handler slog.Handler
+}
+
+// Cria o handler que filtra os registros pelo nível informado.
+func newLevelHandler(level slog.Leveler, handler slog.Handler) *levelHandler {
+	return &levelHandler{level: level, handler: handler}
+}
+
+// Indica se o registro do nível informado deve ser gravado.
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler.Enabled(ctx, level)
+}
+
+// Grava o registro no handler de destino.
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler.Handle(ctx, record)
+}
+
+// Retorna o handler com os atributos informados.
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return newLevelHandler(h.level, h.handler.WithAttrs(attrs))
+}
+
+// Retorna o handler com o grupo informado.
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return newLevelHandler(h.level, h.handler.WithGroup(name))
+}
+
+// Cria o handler que grava os registros no formato informado, com os
+// identificadores do rastreamento. Retorna nil se o formato for none.
+func newWriterHandler(format string, w io.Writer) slog.Handler {
+	options := &slog.HandlerOptions{Level: logLevel}
+	switch format {
+	case LogFormatJSON:
+		return newTraceHandler(slog.NewJSONHandler(w, options))
+	case LogFormatText:
+		return newTraceHandler(slog.NewTextHandler(w, options))
+	}
+	return nil
+}
+
+// Handler que adiciona os identificadores do rastreamento do contexto aos registros.
+type traceHandler struct {
+	handler slog.Handler
+}
+
+// Cria o handler que adiciona trace_id e span_id aos registros do handler informado.
+func newTraceHandler(handler slog.Handler) *traceHandler {
+	return &traceHandler{handler: handler}
+}
+
+// Indica se o registro do nível informado deve ser gravado.
+func (h *traceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Grava o registro com os identificadores do span ativo, se houver.
+func (h *traceHandler) Handle(ctx context.Context, record slog.Record) error {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record = record.Clone()
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+// Retorna o handler com os atributos informados.
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return newTraceHandler(h.handler.WithAttrs(attrs))
+}
+
+// Retorna o handler com o grupo informado.
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	return newTraceHandler(h.handler.WithGroup(name))
+}
+
+// Handler que envia cada registro para vários handlers, como a saída padrão e o OpenTelemetry.
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+// Cria o handler que envia os registros para todos os handlers informados.
+func newFanoutHandler(handlers ...slog.Handler) *fanoutHandler {
+	return &fanoutHandler{handlers: handlers}
+}
+
+// Indica se algum dos handlers grava o registro do nível informado.
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Grava o registro em todos os handlers habilitados, retornando os erros de todos.
+func (h *fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var err error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, record.Level) {
+			err = errors.Join(err, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return err
+}
+
+// Retorna o handler com os atributos informados.
+func (h *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return newFanoutHandler(handlers...)
+}
+
+// Retorna o handler com o grupo informado.
+func (h *fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return newFanoutHandler(handlers...)
+}
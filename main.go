@@ -1,88 +1,81 @@
 package main
 
 import (
-	"api/apis"
-	"api/repositories"
-	"context"
 	"fmt"
 	"log/slog"
 	"os"
-	"path/filepath"
-	"time"
-
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"runtime"
+	"runtime/debug"
 )
 
-var (
-	// configuração da aplicação
-	applicationConfig *Config
-	// encerramento do OTel SDK
-	otelShutdown func(ctx context.Context) error
-)
+// versão da aplicação, definida no build com -ldflags "-X main.version=..."
+var version = "dev"
 
-// inicializa recursos essenciais da aplicação
-func init() {
-	// inicializa o log padrão
-	slog.SetDefault(otelslog.NewLogger(os.Getenv("OTEL_SERVICE_NAME")))
-	// inicializa as configurações da aplicação
-	currentDirectory, err := filepath.Abs(filepath.Dir(os.Args[0]))
-	if err != nil {
-		slog.Error(fmt.Sprintf("%s", err))
-		os.Exit(1)
+// Define um comando da aplicação.
+type command struct {
+	// descrição do comando
+	description string
+	// executa o comando com os argumentos informados
+	run func(args []string) error
+}
+
+// comandos disponíveis na aplicação
+var commands = map[string]command{
+	"serve":   {"start the HTTP server", runServe},
+	"lambda":  {"start the AWS Lambda handler [http|sqs|kinesis|eventbridge|logs]", runLambda},
+	"table":   {"manage the DynamoDB table [create|describe|delete|update-ttl]", runTable},
+	"config":  {"validate or print the configuration [validate|print]", runConfig},
+	"import":  {"import events from a JSONL file", runImport},
+	"export":  {"export events to JSONL on the standard output", runExport},
+	"version": {"print the application version", runVersion},
+}
+
+// ordem de exibição dos comandos na ajuda
+var commandOrder = []string{"serve", "lambda", "table", "config", "import", "export", "version"}
+
+// inicia a aplicação
+func main() {
+	// o log padrão vai para a saída de erro até que a telemetria seja iniciada
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+	// sem argumentos mantém o comportamento anterior de iniciar o servidor HTTP
+	name, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		name, args = os.Args[1], os.Args[2:]
 	}
-	applicationConfig, err = LoadConfig(filepath.Join(currentDirectory, "config.json"))
-	if err != nil {
-		slog.Error(fmt.Sprintf("%s", err))
-		os.Exit(1)
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
 	}
-	// inicializa a telemetria
-	os.Environ()
-	otelShutdown, err = setupOTelSDK(context.Background())
-	if err != nil {
-		slog.Error(fmt.Sprintf("failed to setup OTel SDK: %s", err))
-		os.Exit(1)
+	cmd, ok := commands[name]
+	if !ok {
+		usage()
+		fmt.Fprintf(os.Stderr, "unknown command {%s}\n", name)
+		os.Exit(2)
 	}
-	// inicializa o client do DynamoDB
-	sdkConfig, err := config.LoadDefaultConfig(context.Background())
-	if err != nil {
-		slog.Error(fmt.Sprintf("%s", err))
+	if err := cmd.run(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	applicationConfig.DynamoDBClient = dynamodb.NewFromConfig(sdkConfig)
-	// inicializa o repositório
-	applicationConfig.Repository = repositories.NewMemoryDB(&repositories.MemoryDBConfig{
-		TTL: time.Duration(applicationConfig.RecordTTLMinutes) * time.Minute,
-	})
-	// applicationConfig.Repository = repositories.NewDynamoDBRepository(&repositories.DynamoDBConfig{
-	// 	Log:    applicationConfig.Log,
-	// 	Client: applicationConfig.DynamoDBClient,
-	// 	Table:  "eventos",
-	// 	TTL:    time.Duration(applicationConfig.RecordTTLMinutes) * time.Minute,
-	// })
-	if err := applicationConfig.Repository.Create(context.Background()); err != nil {
-		slog.Error(fmt.Sprintf("failed to create repository: %s", err))
-		os.Exit(1)
+}
+
+// Exibe a ajuda com os comandos disponíveis.
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	for _, name := range commandOrder {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].description)
 	}
 }
 
-// inicia a aplicação
-func main() {
-	// inicia a API no modo Lambda
-	// api := apis.NewLambdaApi(&apis.LambdaApiConfig{
-	// 	Repository: applicationConfig.Repository,
-	// })
-	// inicia a API no modo HTTP
-	api := apis.NewHttpApi(&apis.HttpApiConfig{
-		Address:    applicationConfig.Address,
-		Port:       applicationConfig.Port,
-		Repository: applicationConfig.Repository,
-	})
-	api.Run()
-	// encerra a telemetria
-	err := otelShutdown(context.Background())
-	if err != nil {
-		slog.Error(fmt.Sprintf("failed to shutdown OTel SDK: %s", err))
+// Exibe a versão da aplicação.
+func runVersion(args []string) error {
+	revision := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				revision = setting.Value
+			}
+		}
 	}
+	fmt.Printf("version %s revision %s %s %s/%s\n", version, revision, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
 }
@@ -9,3 +9,12 @@ type ErrorResponse struct {
 	Instance string `json:"instance"`
 	Code     string `json:"code"`
 }
+
+// Define a documentação de um tipo de erro, servida no endereço do campo type das respostas.
+type ErrorType struct {
+	Type        string `json:"type"`
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,22 @@
+package models
+
+const (
+	// dependência ou aplicação saudável
+	HealthStatusOk = "ok"
+	// dependência ou aplicação com falha
+	HealthStatusFail = "fail"
+)
+
+// Define a resposta das verificações de saúde da aplicação.
+type HealthResponse struct {
+	Status string                        `json:"status"`
+	Checks map[string]*HealthCheckResult `json:"checks,omitempty"`
+}
+
+// Define o resultado da verificação de uma dependência.
+type HealthCheckResult struct {
+	Status     string `json:"status"`
+	Critical   bool   `json:"critical"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
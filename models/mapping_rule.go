@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Define a regra de mapeamento de sinais da AWS (EventBridge e CloudWatch Logs) para eventos.
+//
+// Os campos de destino aceitam expressões nos formatos:
+//   - "$.caminho.campo[0]": caminho JSON no documento de origem
+//   - "${grupo}": referência a um grupo nomeado da expressão regular
+//   - qualquer outro valor é usado como literal (e pode conter referências a grupos)
+type MappingRule struct {
+	// nome da regra
+	Name string `json:"name" yaml:"name"`
+	// filtro pela origem (source do EventBridge ou log group do CloudWatch Logs), vazio aceita qualquer origem
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	// filtro pelo detail-type do EventBridge, vazio aceita qualquer tipo
+	DetailType string `json:"detail_type,omitempty" yaml:"detail_type,omitempty"`
+	// expressão regular aplicada à linha de log ou ao detail do EventBridge
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// expressão para a data do evento
+	Date string `json:"date,omitempty" yaml:"date,omitempty"`
+	// expressão para o status code do evento
+	StatusCode string `json:"status_code,omitempty" yaml:"status_code,omitempty"`
+	// expressão para a mensagem do evento
+	StatusMessage string `json:"status_message,omitempty" yaml:"status_message,omitempty"`
+	// expressões para os metadados do evento
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// Valida os campos da regra.
+func (r *MappingRule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("invalid mapping rule name")
+	}
+	if r.Pattern != "" {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern on mapping rule {%s}, %w", r.Name, err)
+		}
+	}
+	return nil
+}
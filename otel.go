@@ -1,25 +1,78 @@
 package main
 
 import (
+	"api/telemetry"
 	"context"
 	"errors"
+	"log/slog"
+	"os"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/detectors/aws/ecs"
+	"go.opentelemetry.io/contrib/detectors/aws/lambda"
+	"go.opentelemetry.io/contrib/instrumentation/runtime"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/attribute"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 )
 
-// configura o SDK OpenTelemetry com exportadores OTLP para rastreamento, métricas e logs.
-func setupOTelSDK(ctx context.Context) (func(context.Context) error, error) {
+const (
+	// exportador OTLP via gRPC
+	ExporterOTLPGRPC = "otlpgrpc"
+	// exportador OTLP via HTTP/protobuf
+	ExporterOTLPHTTP = "otlphttp"
+	// exportador para a saída padrão, útil no desenvolvimento
+	ExporterStdout = "stdout"
+	// sinal desabilitado
+	ExporterNone = "none"
+)
+
+// detectores de recurso disponíveis
+var resourceDetectors = []string{"host", "container", "ecs", "lambda"}
+
+// Opções de configuração do SDK OpenTelemetry.
+type telemetryOptions struct {
+	// exporta spans e logs de forma síncrona, sem acumular em lotes;
+	// usado com a extensão do coletor no AWS Lambda, que recebe os dados localmente
+	syncExport bool
+	// exportador de cada sinal
+	tracesExporter  string
+	metricsExporter string
+	logsExporter    string
+	// endereço do coletor, vazio usa o padrão do SDK ou OTEL_EXPORTER_OTLP_ENDPOINT
+	endpoint string
+	// desabilita o TLS na conexão com o coletor, ignorado quando caFile é informado
+	insecure bool
+	// autoridades certificadoras do coletor
+	caFile string
+	// cabeçalhos enviados ao coletor
+	headers map[string]string
+	// proporção de novos rastreamentos amostrados
+	samplingRatio float64
+	// detectores de recurso habilitados
+	resourceDetectors []string
+	// registro onde as métricas são expostas no formato do Prometheus, nil desabilita
+	prometheusRegistry *prometheus.Registry
+	// convenções semânticas emitidas, vazio mantém as atuais
+	semConv string
+}
+
+// Limites dos buckets dos histogramas de duração em milissegundos.
+var durationBuckets = []float64{1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+
+// configura o SDK OpenTelemetry com os exportadores configurados para rastreamento, métricas e logs.
+// Um sinal cujo exportador não pode ser criado fica desabilitado, sem impedir o início da aplicação.
+// Retorna as funções de desligamento e de descarga dos dados pendentes.
+func setupOTelSDK(ctx context.Context, options telemetryOptions) (func(context.Context) error, func(context.Context) error, error) {
 	var shutdownFuncs []func(context.Context) error
-	var err error
+	var flushFuncs []func(context.Context) error
 	// shutdown executa todas as funções de desligamento registradas.
 	shutdown := func(ctx context.Context) error {
 		var err error
@@ -29,38 +82,55 @@ func setupOTelSDK(ctx context.Context) (func(context.Context) error, error) {
 		shutdownFuncs = nil
 		return err
 	}
-	// lida com erros durante a configuração, garantindo que os recursos sejam liberados.
-	handleErr := func(inErr error) {
-		err = errors.Join(inErr, shutdown(ctx))
+	// flush descarrega os dados pendentes de todos os provedores.
+	flush := func(ctx context.Context) error {
+		var err error
+		for _, fn := range flushFuncs {
+			err = errors.Join(err, fn(ctx))
+		}
+		return err
+	}
+	// define as convenções semânticas antes da criação dos spans e métricas.
+	if options.semConv != "" {
+		if err := telemetry.SetSemConv(options.semConv); err != nil {
+			return nil, nil, err
+		}
 	}
 	// inicia o propagador global.
 	prop := newPropagator()
 	otel.SetTextMapPropagator(prop)
+	res := newResource(ctx, options)
+	// resolve a conexão com o coletor, compartilhada pelos exportadores OTLP.
+	collector := options.collectorConnection()
 	// configura o provedor de rastreamento.
-	tracerProvider, err := newTracerProvider(ctx)
-	if err != nil {
-		handleErr(err)
-		return shutdown, err
+	if tracerProvider, err := newTracerProvider(ctx, options, collector, res); err != nil {
+		telemetryStatus.record("traces", err)
+		slog.Error("traces disabled, unable to create exporter", "error", err)
+	} else if tracerProvider != nil {
+		shutdownFuncs = append(shutdownFuncs, tracerProvider.Shutdown)
+		flushFuncs = append(flushFuncs, tracerProvider.ForceFlush)
+		otel.SetTracerProvider(tracerProvider)
 	}
-	shutdownFuncs = append(shutdownFuncs, tracerProvider.Shutdown)
-	otel.SetTracerProvider(tracerProvider)
 	// configura o provedor de métricas.
-	meterProvider, err := newMeterProvider(ctx)
-	if err != nil {
-		handleErr(err)
-		return shutdown, err
+	if meterProvider := newMeterProvider(ctx, options, collector, res); meterProvider != nil {
+		shutdownFuncs = append(shutdownFuncs, meterProvider.Shutdown)
+		flushFuncs = append(flushFuncs, meterProvider.ForceFlush)
+		otel.SetMeterProvider(meterProvider)
+		// coleta as métricas do runtime do Go, como memória, GC e goroutines
+		if err := runtime.Start(runtime.WithMeterProvider(meterProvider)); err != nil {
+			slog.Warn("unable to collect Go runtime metrics", "error", err)
+		}
 	}
-	shutdownFuncs = append(shutdownFuncs, meterProvider.Shutdown)
-	otel.SetMeterProvider(meterProvider)
 	// configura o provedor de logs.
-	loggerProvider, err := newLoggerProvider(ctx)
-	if err != nil {
-		handleErr(err)
-		return shutdown, err
+	if loggerProvider, err := newLoggerProvider(ctx, options, collector, res); err != nil {
+		telemetryStatus.record("logs", err)
+		slog.Error("logs disabled, unable to create exporter", "error", err)
+	} else if loggerProvider != nil {
+		shutdownFuncs = append(shutdownFuncs, loggerProvider.Shutdown)
+		flushFuncs = append(flushFuncs, loggerProvider.ForceFlush)
+		global.SetLoggerProvider(loggerProvider)
 	}
-	shutdownFuncs = append(shutdownFuncs, loggerProvider.Shutdown)
-	global.SetLoggerProvider(loggerProvider)
-	return shutdown, err
+	return shutdown, flush, nil
 }
 
 // Cria um propagador composto para contexto de rastreamento e bagagem.
@@ -71,44 +141,115 @@ func newPropagator() propagation.TextMapPropagator {
 	)
 }
 
-// Cria um provedor de rastreamento com exportador OTLP via gRPC.
-func newTracerProvider(ctx context.Context) (*trace.TracerProvider, error) {
-	traceExporter, err := otlptracegrpc.New(ctx,
-		otlptracegrpc.WithInsecure(),
-	)
+// Cria o recurso que identifica a aplicação com os detectores habilitados.
+// Falhas na detecção são registradas e o recurso parcial é usado.
+func newResource(ctx context.Context, options telemetryOptions) *resource.Resource {
+	opts := []resource.Option{
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attribute.String("service.version", version)),
+	}
+	for _, detector := range options.resourceDetectors {
+		switch detector {
+		case "host":
+			opts = append(opts, resource.WithHost())
+		case "container":
+			opts = append(opts, resource.WithContainer())
+		case "ecs":
+			opts = append(opts, resource.WithDetectors(ecs.NewResourceDetector()))
+		case "lambda":
+			// o detector do Lambda retorna erro fora do ambiente do Lambda
+			if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
+				opts = append(opts, resource.WithDetectors(lambda.NewResourceDetector()))
+			}
+		}
+	}
+	res, err := resource.New(ctx, opts...)
 	if err != nil {
-		return nil, err
+		slog.Warn("unable to detect all resource attributes", "error", err)
 	}
-	tracerProvider := trace.NewTracerProvider(
-		trace.WithBatcher(traceExporter, trace.WithBatchTimeout(5*time.Second)),
-	)
-	return tracerProvider, nil
+	if res == nil {
+		res = resource.Empty()
+	}
+	merged, err := resource.Merge(resource.Default(), res)
+	if err != nil {
+		// esquemas diferentes entre os detectores, os atributos detectados prevalecem
+		return res
+	}
+	return merged
 }
 
-// Cria um provedor de métricas com exportador OTLP via gRPC.
-func newMeterProvider(ctx context.Context) (*metric.MeterProvider, error) {
-	metricExporter, err := otlpmetricgrpc.New(ctx,
-		otlpmetricgrpc.WithInsecure(),
-	)
-	if err != nil {
+// Cria um provedor de rastreamento com o exportador configurado e amostragem
+// proporcional que respeita a decisão do chamador.
+// Retorna nil se o rastreamento estiver desabilitado.
+func newTracerProvider(ctx context.Context, options telemetryOptions, collector *collectorConnection, res *resource.Resource) (*trace.TracerProvider, error) {
+	traceExporter, err := newSpanExporter(ctx, options, collector)
+	if err != nil || traceExporter == nil {
 		return nil, err
 	}
-	meterProvider := metric.NewMeterProvider(
-		metric.WithReader(metric.NewPeriodicReader(metricExporter, metric.WithInterval(5*time.Second))),
-	)
-	return meterProvider, nil
+	exporter := &statusSpanExporter{traceExporter}
+	providerOptions := []trace.TracerProviderOption{
+		trace.WithResource(res),
+		trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(options.samplingRatio))),
+	}
+	if options.syncExport {
+		providerOptions = append(providerOptions, trace.WithSyncer(exporter))
+	} else {
+		providerOptions = append(providerOptions, trace.WithBatcher(exporter, trace.WithBatchTimeout(5*time.Second)))
+	}
+	return trace.NewTracerProvider(providerOptions...), nil
 }
 
-// Cria um provedor de logs com exportador OTLP via gRPC.
-func newLoggerProvider(ctx context.Context) (*log.LoggerProvider, error) {
-	logExporter, err := otlploggrpc.New(ctx,
-		otlploggrpc.WithInsecure(),
-	)
-	if err != nil {
+// Cria um provedor de métricas com o exportador configurado e, se habilitado,
+// o leitor do Prometheus. Os histogramas em milissegundos usam os buckets de duração.
+// Um leitor que não pode ser criado é registrado e fica desabilitado, sem afetar os demais.
+// Retorna nil se as métricas estiverem desabilitadas.
+func newMeterProvider(ctx context.Context, options telemetryOptions, collector *collectorConnection, res *resource.Resource) *metric.MeterProvider {
+	var readers []metric.Reader
+	if metricExporter, err := newMetricExporter(ctx, options, collector); err != nil {
+		telemetryStatus.record("metrics", err)
+		slog.Error("metrics exporter disabled, unable to create exporter", "error", err)
+	} else if metricExporter != nil {
+		readers = append(readers, metric.NewPeriodicReader(&statusMetricExporter{metricExporter}, metric.WithInterval(5*time.Second)))
+	}
+	if options.prometheusRegistry != nil {
+		if reader, err := otelprometheus.New(otelprometheus.WithRegisterer(options.prometheusRegistry)); err != nil {
+			telemetryStatus.record("prometheus", err)
+			slog.Error("Prometheus metrics disabled, unable to create exporter", "error", err)
+		} else {
+			readers = append(readers, reader)
+		}
+	}
+	if len(readers) == 0 {
+		return nil
+	}
+	providerOptions := []metric.Option{
+		metric.WithResource(res),
+		metric.WithView(metric.NewView(
+			metric.Instrument{Kind: metric.InstrumentKindHistogram, Unit: "ms"},
+			metric.Stream{Aggregation: metric.AggregationExplicitBucketHistogram{Boundaries: durationBuckets}},
+		)),
+	}
+	for _, reader := range readers {
+		providerOptions = append(providerOptions, metric.WithReader(reader))
+	}
+	return metric.NewMeterProvider(providerOptions...)
+}
+
+// Cria um provedor de logs com o exportador configurado.
+// Retorna nil se os logs estiverem desabilitados.
+func newLoggerProvider(ctx context.Context, options telemetryOptions, collector *collectorConnection, res *resource.Resource) (*log.LoggerProvider, error) {
+	logExporter, err := newLogExporter(ctx, options, collector)
+	if err != nil || logExporter == nil {
 		return nil, err
 	}
+	exporter := &statusLogExporter{logExporter}
+	if options.syncExport {
+		return log.NewLoggerProvider(log.WithResource(res), log.WithProcessor(log.NewSimpleProcessor(exporter))), nil
+	}
 	loggerProvider := log.NewLoggerProvider(
-		log.WithProcessor(log.NewBatchProcessor(logExporter)),
+		log.WithResource(res),
+		log.WithProcessor(log.NewBatchProcessor(exporter)),
 	)
 	return loggerProvider, nil
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Instrumenta os clientes do SDK da AWS criados com a configuração informada:
+// cada chamada à API gera um span filho do span do contexto, com o aws.request_id,
+// o status HTTP, as novas tentativas e os códigos de erro.
+func instrumentAWSConfig(cfg *aws.Config) {
+	otelaws.AppendMiddlewares(&cfg.APIOptions)
+	// adicionado depois do otelaws para executar dentro do span da chamada
+	cfg.APIOptions = append(cfg.APIOptions, attemptsMiddleware)
+}
+
+// Middleware do SDK da AWS que adiciona ao span da chamada as tentativas
+// registradas pelo retryer do SDK e o código do erro final.
+func attemptsMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("OTelAttempts", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+		middleware.InitializeOutput, middleware.Metadata, error,
+	) {
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		span := trace.SpanFromContext(ctx)
+		if results, ok := retry.GetAttemptResults(metadata); ok {
+			span.SetAttributes(attribute.Int("aws.attempts", len(results.Results)))
+			if len(results.Results) > 1 {
+				span.SetAttributes(attribute.Int("http.request.resend_count", len(results.Results)-1))
+			}
+			for i, result := range results.Results {
+				if result.Err == nil {
+					continue
+				}
+				attrs := []attribute.KeyValue{
+					attribute.Int("aws.attempt", i+1),
+					attribute.Bool("aws.retryable", result.Retryable),
+				}
+				if code := awsErrorCode(result.Err); code != "" {
+					attrs = append(attrs, attribute.String("aws.error.code", code))
+				}
+				span.AddEvent("attempt failed", trace.WithAttributes(attrs...))
+			}
+		}
+		if code := awsErrorCode(err); code != "" {
+			span.SetAttributes(attribute.String("aws.error.code", code))
+		}
+		return out, metadata, err
+	}), middleware.After)
+}
+
+// Retorna o código do erro da API da AWS, como ProvisionedThroughputExceededException,
+// ou vazio se o erro não veio da API.
+func awsErrorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+// Define a conexão com o coletor, resolvida uma única vez e compartilhada pelos exportadores OTLP.
+type collectorConnection struct {
+	// endereço do coletor no formato host:porta, vazio usa o padrão do SDK
+	endpoint string
+	// URL do coletor, cujo esquema (http ou https) define o uso de TLS
+	endpointURL string
+	// desabilita o TLS
+	insecure bool
+	// configuração de TLS ou nil para usar a padrão
+	tlsConfig *tls.Config
+	// cabeçalhos enviados ao coletor
+	headers map[string]string
+	// erro ao carregar as autoridades certificadoras, retornado pelos exportadores OTLP
+	err error
+}
+
+// Resolve a conexão com o coletor a partir das opções de telemetria, uma única vez para
+// todos os sinais. Uma falha fica na conexão e só desabilita os sinais exportados por OTLP.
+// Quando o endereço é uma URL, o esquema define o uso de TLS e a opção insecure é ignorada;
+// as autoridades certificadoras informadas implicam o uso de TLS, mesmo com insecure.
+func (o *telemetryOptions) collectorConnection() *collectorConnection {
+	c := &collectorConnection{headers: o.headers}
+	if strings.Contains(o.endpoint, "://") {
+		c.endpointURL = o.endpoint
+	} else {
+		c.endpoint = o.endpoint
+		c.insecure = o.insecure && o.caFile == ""
+	}
+	if o.caFile == "" {
+		return c
+	}
+	data, err := os.ReadFile(o.caFile)
+	if err != nil {
+		c.err = fmt.Errorf("unable to read telemetry CA file, %w", err)
+		return c
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		c.err = fmt.Errorf("no certificates found in telemetry CA file %s", o.caFile)
+		return c
+	}
+	c.tlsConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return c
+}
+
+// Associa cada item da conexão com o coletor à opção correspondente de um exportador OTLP.
+type exporterOptions[T any] struct {
+	headers     func(map[string]string) T
+	endpoint    func(string) T
+	endpointURL func(string) T
+	insecure    func() T
+	tlsConfig   func(*tls.Config) T
+}
+
+// Converte a conexão com o coletor nas opções do exportador.
+func (m exporterOptions[T]) options(c *collectorConnection) []T {
+	opts := []T{m.headers(c.headers)}
+	switch {
+	case c.endpointURL != "":
+		opts = append(opts, m.endpointURL(c.endpointURL))
+	case c.endpoint != "":
+		opts = append(opts, m.endpoint(c.endpoint))
+	}
+	switch {
+	case c.insecure:
+		opts = append(opts, m.insecure())
+	case c.tlsConfig != nil:
+		opts = append(opts, m.tlsConfig(c.tlsConfig))
+	}
+	return opts
+}
+
+var (
+	traceGRPCOptions = exporterOptions[otlptracegrpc.Option]{
+		headers:     otlptracegrpc.WithHeaders,
+		endpoint:    otlptracegrpc.WithEndpoint,
+		endpointURL: otlptracegrpc.WithEndpointURL,
+		insecure:    otlptracegrpc.WithInsecure,
+		tlsConfig: func(c *tls.Config) otlptracegrpc.Option {
+			return otlptracegrpc.WithTLSCredentials(credentials.NewTLS(c))
+		},
+	}
+	traceHTTPOptions = exporterOptions[otlptracehttp.Option]{
+		headers:     otlptracehttp.WithHeaders,
+		endpoint:    otlptracehttp.WithEndpoint,
+		endpointURL: otlptracehttp.WithEndpointURL,
+		insecure:    otlptracehttp.WithInsecure,
+		tlsConfig:   otlptracehttp.WithTLSClientConfig,
+	}
+	metricGRPCOptions = exporterOptions[otlpmetricgrpc.Option]{
+		headers:     otlpmetricgrpc.WithHeaders,
+		endpoint:    otlpmetricgrpc.WithEndpoint,
+		endpointURL: otlpmetricgrpc.WithEndpointURL,
+		insecure:    otlpmetricgrpc.WithInsecure,
+		tlsConfig: func(c *tls.Config) otlpmetricgrpc.Option {
+			return otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(c))
+		},
+	}
+	metricHTTPOptions = exporterOptions[otlpmetrichttp.Option]{
+		headers:     otlpmetrichttp.WithHeaders,
+		endpoint:    otlpmetrichttp.WithEndpoint,
+		endpointURL: otlpmetrichttp.WithEndpointURL,
+		insecure:    otlpmetrichttp.WithInsecure,
+		tlsConfig:   otlpmetrichttp.WithTLSClientConfig,
+	}
+	logGRPCOptions = exporterOptions[otlploggrpc.Option]{
+		headers:     otlploggrpc.WithHeaders,
+		endpoint:    otlploggrpc.WithEndpoint,
+		endpointURL: otlploggrpc.WithEndpointURL,
+		insecure:    otlploggrpc.WithInsecure,
+		tlsConfig: func(c *tls.Config) otlploggrpc.Option {
+			return otlploggrpc.WithTLSCredentials(credentials.NewTLS(c))
+		},
+	}
+	logHTTPOptions = exporterOptions[otlploghttp.Option]{
+		headers:     otlploghttp.WithHeaders,
+		endpoint:    otlploghttp.WithEndpoint,
+		endpointURL: otlploghttp.WithEndpointURL,
+		insecure:    otlploghttp.WithInsecure,
+		tlsConfig:   otlploghttp.WithTLSClientConfig,
+	}
+)
+
+// Cria o exportador de rastreamento configurado ou nil se ele estiver desabilitado.
+func newSpanExporter(ctx context.Context, o telemetryOptions, c *collectorConnection) (trace.SpanExporter, error) {
+	switch o.tracesExporter {
+	case ExporterOTLPGRPC, ExporterOTLPHTTP:
+		if c.err != nil {
+			return nil, c.err
+		}
+		if o.tracesExporter == ExporterOTLPGRPC {
+			return otlptracegrpc.New(ctx, traceGRPCOptions.options(c)...)
+		}
+		return otlptracehttp.New(ctx, traceHTTPOptions.options(c)...)
+	case ExporterStdout:
+		return stdouttrace.New()
+	}
+	return nil, nil
+}
+
+// Cria o exportador de métricas configurado ou nil se ele estiver desabilitado.
+func newMetricExporter(ctx context.Context, o telemetryOptions, c *collectorConnection) (metric.Exporter, error) {
+	switch o.metricsExporter {
+	case ExporterOTLPGRPC, ExporterOTLPHTTP:
+		if c.err != nil {
+			return nil, c.err
+		}
+		if o.metricsExporter == ExporterOTLPGRPC {
+			return otlpmetricgrpc.New(ctx, metricGRPCOptions.options(c)...)
+		}
+		return otlpmetrichttp.New(ctx, metricHTTPOptions.options(c)...)
+	case ExporterStdout:
+		return stdoutmetric.New()
+	}
+	return nil, nil
+}
+
+// Cria o exportador de logs configurado ou nil se ele estiver desabilitado.
+func newLogExporter(ctx context.Context, o telemetryOptions, c *collectorConnection) (log.Exporter, error) {
+	switch o.logsExporter {
+	case ExporterOTLPGRPC, ExporterOTLPHTTP:
+		if c.err != nil {
+			return nil, c.err
+		}
+		if o.logsExporter == ExporterOTLPGRPC {
+			return otlploggrpc.New(ctx, logGRPCOptions.options(c)...)
+		}
+		return otlploghttp.New(ctx, logHTTPOptions.options(c)...)
+	case ExporterStdout:
+		return stdoutlog.New()
+	}
+	return nil, nil
+}
@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"api/interfaces"
+	"api/models"
+	"container/list"
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Define a configuração do cache de leitura do repositório.
+type CacheConfig struct {
+	// repositório de dados decorado pelo cache
+	Repository interfaces.Repository
+	// quantidade máxima de registros mantidos, os menos usados são descartados
+	Size int
+	// tempo que um registro encontrado permanece no cache
+	TTL time.Duration
+	// tempo que um registro não encontrado permanece no cache, zero desativa
+	NegativeTTL time.Duration
+}
+
+// Item do cache: o registro encontrado ou nil quando ele não existe.
+type cacheEntry struct {
+	id      string
+	event   *models.Event
+	expires time.Time
+}
+
+// Consulta ao repositório em andamento para um id ausente do cache, compartilhada pelas
+// leituras simultâneas; invalidada quando o registro é alterado antes do fim da consulta.
+type cacheLoad struct {
+	readers     int
+	invalidated bool
+}
+
+// Define a estrutura do cache de leitura (read-through) do repositório.
+// As consultas por id são atendidas pelo cache e as demais operações são repassadas ao
+// repositório; Save, SaveBatch e Delete removem do cache os registros alterados.
+// O cache é local à instância: alterações feitas por outras instâncias só são vistas
+// após o TTL ou após Invalidate.
+type Cache struct {
+	interfaces.Repository
+	// configuração do cache
+	config *CacheConfig
+	// itens do cache, do mais para o menos usado
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	// consultas em andamento por id, impedem que uma leitura anterior à invalidação seja guardada
+	loads map[string]*cacheLoad
+	// métricas do cache
+	hitCounter        metric.Int64Counter
+	missCounter       metric.Int64Counter
+	itemsRegistration metric.Registration
+}
+
+// Cria o cache de leitura sobre o repositório informado.
+func NewCache(config *CacheConfig) *Cache {
+	p := &Cache{
+		Repository: config.Repository,
+		config:     config,
+		entries:    make(map[string]*list.Element),
+		loads:      make(map[string]*cacheLoad),
+		lru:        list.New(),
+	}
+	meter := otel.Meter("repository.metrics")
+	if counter, err := meter.Int64Counter("custom.repository.cache.hits.total",
+		metric.WithDescription("The number of repository reads served by the cache"),
+		metric.WithUnit("{hits}")); err == nil {
+		p.hitCounter = counter
+	} else {
+		panic(err)
+	}
+	if counter, err := meter.Int64Counter("custom.repository.cache.misses.total",
+		metric.WithDescription("The number of repository reads not found in the cache"),
+		metric.WithUnit("{misses}")); err == nil {
+		p.missCounter = counter
+	} else {
+		panic(err)
+	}
+	if gauge, err := meter.Int64ObservableGauge("custom.repository.cache.items",
+		metric.WithDescription("The number of items stored in the cache"),
+		metric.WithUnit("{items}")); err == nil {
+		registration, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			o.ObserveInt64(gauge, int64(p.lru.Len()))
+			return nil
+		}, gauge)
+		if err != nil {
+			panic(err)
+		}
+		p.itemsRegistration = registration
+	} else {
+		panic(err)
+	}
+	return p
+}
+
+// Libera os recursos do cache e do repositório.
+func (p *Cache) Close(ctx context.Context) error {
+	return errors.Join(p.itemsRegistration.Unregister(), p.Repository.Close(ctx))
+}
+
+// Retorna o registro pelo id, consultando o repositório apenas se ele não estiver no cache.
+// Registros inexistentes também são guardados, por NegativeTTL, e retornam ErrNotFound.
+func (p *Cache) Get(ctx context.Context, id string) (*models.Event, error) {
+	span := trace.SpanFromContext(ctx)
+	entry, load, ok := p.lookup(id)
+	if ok {
+		negative := attribute.Bool("cache.negative", entry.event == nil)
+		p.hitCounter.Add(ctx, 1, metric.WithAttributes(negative))
+		span.AddEvent("cache hit", trace.WithAttributes(negative))
+		if entry.event == nil {
+			return nil, newError(ErrNotFound, "get", nil)
+		}
+		return cloneEvent(entry.event), nil
+	}
+	p.missCounter.Add(ctx, 1)
+	span.AddEvent("cache miss")
+	event, err := p.Repository.Get(ctx, id)
+	switch {
+	case err == nil:
+		p.store(id, load, cloneEvent(event), p.config.TTL)
+	case errors.Is(err, ErrNotFound):
+		p.store(id, load, nil, p.config.NegativeTTL)
+	default:
+		p.store(id, load, nil, 0)
+	}
+	return event, err
+}
+
+// Salva o registro no repositório, removendo a versão anterior do cache.
+func (p *Cache) Save(ctx context.Context, event *models.Event) error {
+	// o id pode ser gerado pelo repositório, por isso a remoção ocorre depois da gravação
+	defer func() { p.Invalidate(event.Id) }()
+	return p.Repository.Save(ctx, event)
+}
+
+// Salva os registros no repositório, removendo as versões anteriores do cache.
+func (p *Cache) SaveBatch(ctx context.Context, events []*models.Event) ([]*models.Event, error) {
+	defer func() {
+		ids := make([]string, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.Id)
+		}
+		p.Invalidate(ids...)
+	}()
+	return p.Repository.SaveBatch(ctx, events)
+}
+
+// Remove o registro do repositório e do cache.
+func (p *Cache) Delete(ctx context.Context, id string) (*models.Event, error) {
+	defer p.Invalidate(id)
+	return p.Repository.Delete(ctx, id)
+}
+
+// Remove os registros do cache, forçando a próxima leitura no repositório.
+// Pode ser usado para propagar alterações feitas por outras instâncias.
+func (p *Cache) Invalidate(ids ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, id := range ids {
+		// as consultas em andamento descartam o resultado e as próximas iniciam uma nova
+		if load, ok := p.loads[id]; ok {
+			load.invalidated = true
+			delete(p.loads, id)
+		}
+		if element, ok := p.entries[id]; ok {
+			p.lru.Remove(element)
+			delete(p.entries, id)
+		}
+	}
+}
+
+// Procura o registro válido no cache. Em caso de falta, registra a consulta ao
+// repositório, que deve ser encerrada com store.
+func (p *Cache) lookup(id string) (*cacheEntry, *cacheLoad, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if element, ok := p.entries[id]; ok {
+		entry := element.Value.(*cacheEntry)
+		if time.Now().Before(entry.expires) {
+			p.lru.MoveToFront(element)
+			return entry, nil, true
+		}
+		p.lru.Remove(element)
+		delete(p.entries, id)
+	}
+	load, ok := p.loads[id]
+	if !ok {
+		load = &cacheLoad{}
+		p.loads[id] = load
+	}
+	load.readers++
+	return nil, load, false
+}
+
+// Encerra a consulta e guarda o resultado, se o registro não foi invalidado durante ela,
+// descartando os registros menos usados acima do limite.
+func (p *Cache) store(id string, load *cacheLoad, event *models.Event, ttl time.Duration) {
+	expires := time.Now().Add(ttl)
+	// o registro não fica no cache além da própria expiração
+	if event != nil && event.Expiration != 0 {
+		if expiration := time.Unix(event.Expiration, 0); expiration.Before(expires) {
+			expires = expiration
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	load.readers--
+	if load.readers == 0 && p.loads[id] == load {
+		delete(p.loads, id)
+	}
+	if load.invalidated || ttl <= 0 || p.config.Size <= 0 {
+		return
+	}
+	if element, ok := p.entries[id]; ok {
+		element.Value = &cacheEntry{id: id, event: event, expires: expires}
+		p.lru.MoveToFront(element)
+		return
+	}
+	p.entries[id] = p.lru.PushFront(&cacheEntry{id: id, event: event, expires: expires})
+	for p.lru.Len() > p.config.Size {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		delete(p.entries, oldest.Value.(*cacheEntry).id)
+	}
+}
+
+// Copia o registro para que alterações feitas pelo chamador não afetem o cache.
+func cloneEvent(event *models.Event) *models.Event {
+	if event == nil {
+		return nil
+	}
+	clone := *event
+	clone.Metadata = maps.Clone(event.Metadata)
+	return &clone
+}
@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"api/interfaces"
+	"api/models"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Repositório de teste: Get responde com a função informada e conta as consultas por id.
+type fakeRepository struct {
+	interfaces.Repository
+	get   func(ctx context.Context, id string) (*models.Event, error)
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newFakeRepository(get func(ctx context.Context, id string) (*models.Event, error)) *fakeRepository {
+	return &fakeRepository{get: get, calls: make(map[string]int)}
+}
+
+func (f *fakeRepository) Get(ctx context.Context, id string) (*models.Event, error) {
+	f.mu.Lock()
+	f.calls[id]++
+	f.mu.Unlock()
+	return f.get(ctx, id)
+}
+
+func (f *fakeRepository) Calls(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[id]
+}
+
+// Retorna um registro com o próprio id, ou ErrNotFound para o id "missing".
+func getEvent(ctx context.Context, id string) (*models.Event, error) {
+	if id == "missing" {
+		return nil, newError(ErrNotFound, "get", nil)
+	}
+	return &models.Event{Id: id, StatusCode: 200, Metadata: map[string]string{"key": "value"}}, nil
+}
+
+func TestCacheGet(t *testing.T) {
+	unavailable := func(ctx context.Context, id string) (*models.Event, error) {
+		return nil, newError(ErrUnavailable, "get", nil)
+	}
+	expired := func(ctx context.Context, id string) (*models.Event, error) {
+		return &models.Event{Id: id, Expiration: time.Now().Add(-time.Minute).Unix()}, nil
+	}
+	tests := []struct {
+		name    string
+		config  CacheConfig
+		get     func(ctx context.Context, id string) (*models.Event, error)
+		id      string
+		wantErr error
+		// consultas ao repositório após duas leituras
+		wantCalls int
+	}{
+		{name: "found is cached", config: CacheConfig{Size: 10, TTL: time.Minute}, get: getEvent, id: "a", wantCalls: 1},
+		{name: "not found is cached with negative ttl", config: CacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute}, get: getEvent, id: "missing", wantErr: ErrNotFound, wantCalls: 1},
+		{name: "not found is not cached without negative ttl", config: CacheConfig{Size: 10, TTL: time.Minute}, get: getEvent, id: "missing", wantErr: ErrNotFound, wantCalls: 2},
+		{name: "failures are not cached", config: CacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute}, get: unavailable, id: "a", wantErr: ErrUnavailable, wantCalls: 2},
+		{name: "expired ttl is reloaded", config: CacheConfig{Size: 10, TTL: time.Nanosecond}, get: getEvent, id: "a", wantCalls: 2},
+		{name: "record expiration limits the ttl", config: CacheConfig{Size: 10, TTL: time.Minute}, get: expired, id: "a", wantCalls: 2},
+		{name: "zero size disables the cache", config: CacheConfig{TTL: time.Minute}, get: getEvent, id: "a", wantCalls: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := newFakeRepository(test.get)
+			test.config.Repository = repository
+			cache := NewCache(&test.config)
+			for range 2 {
+				event, err := cache.Get(context.Background(), test.id)
+				if test.wantErr != nil {
+					if !errors.Is(err, test.wantErr) {
+						t.Fatalf("error = %v, want %v", err, test.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if event.Id != test.id {
+					t.Fatalf("id = %q, want %q", event.Id, test.id)
+				}
+			}
+			if calls := repository.Calls(test.id); calls != test.wantCalls {
+				t.Errorf("repository calls = %d, want %d", calls, test.wantCalls)
+			}
+			if len(cache.loads) != 0 {
+				t.Errorf("loads = %d, want 0", len(cache.loads))
+			}
+		})
+	}
+}
+
+func TestCacheGetReturnsCopies(t *testing.T) {
+	cache := NewCache(&CacheConfig{Repository: newFakeRepository(getEvent), Size: 10, TTL: time.Minute})
+	event, err := cache.Get(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.StatusCode = 500
+	event.Metadata["key"] = "changed"
+	cached, err := cache.Get(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.StatusCode != 200 || cached.Metadata["key"] != "value" {
+		t.Errorf("cached event changed by the caller: %+v", cached)
+	}
+}
+
+func TestCacheInvalidateDuringLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate string
+		// consultas ao repositório após a leitura concorrente e uma nova leitura
+		wantCalls int
+	}{
+		{name: "invalidated record is discarded", invalidate: "a", wantCalls: 2},
+		{name: "unrelated invalidation keeps the record", invalidate: "b", wantCalls: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			started, release := make(chan struct{}), make(chan struct{})
+			// apenas a primeira consulta aguarda a invalidação
+			var once sync.Once
+			repository := newFakeRepository(func(ctx context.Context, id string) (*models.Event, error) {
+				once.Do(func() {
+					close(started)
+					<-release
+				})
+				return getEvent(ctx, id)
+			})
+			cache := NewCache(&CacheConfig{Repository: repository, Size: 10, TTL: time.Minute})
+			done := make(chan error)
+			go func() {
+				_, err := cache.Get(context.Background(), "a")
+				done <- err
+			}()
+			<-started
+			cache.Invalidate(test.invalidate)
+			close(release)
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cache.Get(context.Background(), "a"); err != nil {
+				t.Fatal(err)
+			}
+			if calls := repository.Calls("a"); calls != test.wantCalls {
+				t.Errorf("repository calls = %d, want %d", calls, test.wantCalls)
+			}
+			if len(cache.loads) != 0 {
+				t.Errorf("loads = %d, want 0", len(cache.loads))
+			}
+		})
+	}
+}
+
+func TestCacheInvalidateDuringSharedLoad(t *testing.T) {
+	// duas leituras compartilham a consulta invalidada e uma terceira inicia outra
+	arrived, release := make(chan struct{}, 3), make(chan struct{})
+	repository := newFakeRepository(func(ctx context.Context, id string) (*models.Event, error) {
+		arrived <- struct{}{}
+		<-release
+		return getEvent(ctx, id)
+	})
+	cache := NewCache(&CacheConfig{Repository: repository, Size: 10, TTL: time.Minute})
+	var wg sync.WaitGroup
+	read := func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Get(context.Background(), "a"); err != nil {
+				t.Error(err)
+			}
+		}()
+		<-arrived
+	}
+	read()
+	read()
+	cache.mu.Lock()
+	first := cache.loads["a"]
+	cache.mu.Unlock()
+	if first == nil || first.readers != 2 {
+		t.Fatalf("shared load = %+v, want 2 readers", first)
+	}
+	cache.Invalidate("a")
+	read()
+	cache.mu.Lock()
+	second := cache.loads["a"]
+	cache.mu.Unlock()
+	if second == nil || second == first || second.readers != 1 {
+		t.Fatalf("new load = %+v, want a new load with 1 reader", second)
+	}
+	close(release)
+	wg.Wait()
+	if len(cache.loads) != 0 {
+		t.Errorf("loads = %d, want 0", len(cache.loads))
+	}
+	// apenas a consulta iniciada após a invalidação é guardada
+	if _, ok := cache.entries["a"]; !ok {
+		t.Error("record loaded after the invalidation was not cached")
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	tests := []struct {
+		name  string
+		reads []string
+		// consultas ao repositório por id ao final das leituras
+		wantCalls map[string]int
+	}{
+		{name: "least recently used is evicted", reads: []string{"a", "b", "c", "a"}, wantCalls: map[string]int{"a": 2, "b": 1, "c": 1}},
+		{name: "hit refreshes the order", reads: []string{"a", "b", "a", "c", "a", "b"}, wantCalls: map[string]int{"a": 1, "b": 2, "c": 1}},
+		{name: "within size is kept", reads: []string{"a", "b", "a", "b"}, wantCalls: map[string]int{"a": 1, "b": 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := newFakeRepository(getEvent)
+			cache := NewCache(&CacheConfig{Repository: repository, Size: 2, TTL: time.Minute})
+			for _, id := range test.reads {
+				if _, err := cache.Get(context.Background(), id); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for id, want := range test.wantCalls {
+				if calls := repository.Calls(id); calls != want {
+					t.Errorf("repository calls for %q = %d, want %d", id, calls, want)
+				}
+			}
+			if cache.lru.Len() != len(cache.entries) || cache.lru.Len() > 2 {
+				t.Errorf("lru = %d, entries = %d, want at most 2", cache.lru.Len(), len(cache.entries))
+			}
+		})
+	}
+}
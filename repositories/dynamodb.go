@@ -3,10 +3,14 @@ package repositories
 import (
 	"api/interfaces"
 	"api/models"
+	"api/telemetry"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -19,6 +23,15 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	// quantidade máxima de itens por chamada do BatchWriteItem
+	dynamoDBBatchSize = 25
+	// quantidade de novas tentativas para itens não processados
+	dynamoDBBatchRetries = 5
+	// sistema de banco de dados nos atributos da telemetria
+	dynamoDBSystem = "aws.dynamodb"
+)
+
 // Define a configuração do repositório do DynamoDB.
 type DynamoDBConfig struct {
 	// cliente do DynamoDB
@@ -29,20 +42,62 @@ type DynamoDBConfig struct {
 	TTL time.Duration
 }
 
+// Define a descrição da tabela DynamoDB.
+type TableDescription struct {
+	// nome da tabela
+	Name string `json:"name"`
+	// estado da tabela
+	Status string `json:"status"`
+	// quantidade aproximada de itens (atualizada pela AWS a cada 6 horas)
+	ItemCount int64 `json:"itemCount"`
+	// tamanho aproximado da tabela em bytes
+	SizeBytes int64 `json:"sizeBytes"`
+	// índices secundários globais
+	Indexes []IndexDescription `json:"indexes"`
+	// estado do TTL
+	TTLStatus string `json:"ttlStatus"`
+	// atributo usado pelo TTL
+	TTLAttribute string `json:"ttlAttribute,omitempty"`
+}
+
+// Define a descrição de um índice secundário global.
+type IndexDescription struct {
+	// nome do índice
+	Name string `json:"name"`
+	// estado do índice
+	Status string `json:"status"`
+	// quantidade aproximada de itens
+	ItemCount int64 `json:"itemCount"`
+}
+
 // Define a estrutura do repositório do DynamoDB.
 type DynamoDB struct {
 	// cliente do DynamoDB
 	config *DynamoDBConfig
+	// tempo de expiração dos registros, alterável sem reiniciar a aplicação
+	ttl atomic.Int64
 	// configura o tracer
 	tracer trace.Tracer
+	// métricas das operações e da capacidade consumida
+	metrics         *repositoryMetrics
+	dynamoDBMetrics *dynamoDBMetrics
 }
 
 // Cria uma nova instância do repositório do DynamoDB.
 func NewDynamoDBRepository(config *DynamoDBConfig) *DynamoDB {
-	return &DynamoDB{
-		config: config,
-		tracer: otel.Tracer("dynamodb.repository"),
+	p := &DynamoDB{
+		config:          config,
+		tracer:          otel.Tracer("dynamodb.repository"),
+		metrics:         newRepositoryMetrics(dynamoDBSystem),
+		dynamoDBMetrics: newDynamoDBMetrics(),
 	}
+	p.ttl.Store(int64(config.TTL))
+	return p
+}
+
+// Altera o tempo de expiração dos novos registros.
+func (p *DynamoDB) SetTTL(ttl time.Duration) {
+	p.ttl.Store(int64(ttl))
 }
 
 // Cria um span contextualizado para o banco de dados de memória.
@@ -51,14 +106,10 @@ func (p *DynamoDB) newSpan(ctx context.Context, operation string, statement stri
 		ctx,
 		operation,
 		trace.WithSpanKind(trace.SpanKindClient),
-		trace.WithAttributes(
-			attribute.String("db.system", "aws.dynamodb"),
-			attribute.String("db.name", p.config.Table),
-			attribute.String("db.operation", operation),
-		),
+		trace.WithAttributes(telemetry.DBAttributes(dynamoDBSystem, p.config.Table, operation)...),
 	)
 	if statement != "" {
-		span.SetAttributes(attribute.String("db.statement", statement))
+		span.SetAttributes(telemetry.DBQueryText(statement)...)
 	}
 	return ctx, span
 }
@@ -67,6 +118,7 @@ func (p *DynamoDB) newSpan(ctx context.Context, operation string, statement stri
 func (p *DynamoDB) Create(ctx context.Context) error {
 	ctx, span := p.newSpan(ctx, "create-table", "")
 	defer span.End()
+	defer p.metrics.observe(ctx, "create-table", time.Now())
 	_, err := p.config.Client.CreateTable(ctx, &dynamodb.CreateTableInput{
 		AttributeDefinitions: []types.AttributeDefinition{
 			{
@@ -112,7 +164,7 @@ func (p *DynamoDB) Create(ctx context.Context) error {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to create table")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to create table, %s", err))
+		slog.ErrorContext(ctx, "unable to create table", "error", err)
 		if strings.Contains(err.Error(), "already exists") {
 			return nil
 		}
@@ -125,81 +177,297 @@ func (p *DynamoDB) Create(ctx context.Context) error {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to check if table are ready")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to check if table are ready, %s", err))
+		slog.ErrorContext(ctx, "unable to check if table are ready", "error", err)
 		return err
 	}
 	span.AddEvent("table ready")
 	// só é possível habilitar TTL na tabela após ela ter sido criada
-	_, err = p.config.Client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+	return p.UpdateTTL(ctx, true)
+}
+
+// Habilita ou desabilita o TTL da tabela pelo atributo expiration.
+func (p *DynamoDB) UpdateTTL(ctx context.Context, enabled bool) error {
+	ctx, span := p.newSpan(ctx, "update-time-to-live", "")
+	defer span.End()
+	defer p.metrics.observe(ctx, "update-time-to-live", time.Now())
+	_, err := p.config.Client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
 		TableName: &p.config.Table,
 		TimeToLiveSpecification: &types.TimeToLiveSpecification{
 			AttributeName: aws.String("expiration"),
-			Enabled:       aws.Bool(true),
+			Enabled:       aws.Bool(enabled),
 		},
 	})
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to configure TTL on table")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to configure TTL on table, %s", err))
+		slog.ErrorContext(ctx, "unable to configure TTL on table", "error", err)
 		return err
 	}
 	return nil
 }
 
+// Remove a tabela DynamoDB e aguarda até que ela deixe de existir.
+func (p *DynamoDB) Drop(ctx context.Context) error {
+	ctx, span := p.newSpan(ctx, "delete-table", "")
+	defer span.End()
+	defer p.metrics.observe(ctx, "delete-table", time.Now())
+	_, err := p.config.Client.DeleteTable(ctx, &dynamodb.DeleteTableInput{
+		TableName: &p.config.Table,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to delete table")
+		slog.ErrorContext(ctx, "unable to delete table", "error", err)
+		return err
+	}
+	span.AddEvent("waiting for table to be deleted")
+	waiter := dynamodb.NewTableNotExistsWaiter(p.config.Client)
+	err = waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: &p.config.Table}, 5*time.Minute)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to check if table was deleted")
+		slog.ErrorContext(ctx, "unable to check if table was deleted", "error", err)
+		return err
+	}
+	return nil
+}
+
+// Libera os recursos do repositório (não há dados pendentes no caso do DynamoDB).
+func (p *DynamoDB) Close(ctx context.Context) error {
+	return nil
+}
+
+// Verifica se a tabela DynamoDB está acessível e ativa.
+func (p *DynamoDB) Ping(ctx context.Context) error {
+	ctx, span := p.newSpan(ctx, "ping", "")
+	defer span.End()
+	defer p.metrics.observe(ctx, "ping", time.Now())
+	table, err := p.config.Client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: &p.config.Table,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to describe table")
+		return dynamoDBError("ping", err)
+	}
+	if status := table.Table.TableStatus; status != types.TableStatusActive {
+		err := newError(ErrUnavailable, "ping", fmt.Errorf("table {%s} status is %s", p.config.Table, status))
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// Descreve o estado da tabela DynamoDB, dos índices e do TTL.
+func (p *DynamoDB) Describe(ctx context.Context) (*TableDescription, error) {
+	ctx, span := p.newSpan(ctx, "describe-table", "")
+	defer span.End()
+	defer p.metrics.observe(ctx, "describe-table", time.Now())
+	table, err := p.config.Client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: &p.config.Table,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to describe table")
+		slog.ErrorContext(ctx, "unable to describe table", "error", err)
+		return nil, err
+	}
+	ttl, err := p.config.Client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: &p.config.Table,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to describe table TTL")
+		slog.ErrorContext(ctx, "unable to describe table TTL", "error", err)
+		return nil, err
+	}
+	description := &TableDescription{
+		Name:      p.config.Table,
+		Status:    string(table.Table.TableStatus),
+		ItemCount: aws.ToInt64(table.Table.ItemCount),
+		SizeBytes: aws.ToInt64(table.Table.TableSizeBytes),
+	}
+	for _, index := range table.Table.GlobalSecondaryIndexes {
+		description.Indexes = append(description.Indexes, IndexDescription{
+			Name:      aws.ToString(index.IndexName),
+			Status:    string(index.IndexStatus),
+			ItemCount: aws.ToInt64(index.ItemCount),
+		})
+	}
+	if ttl.TimeToLiveDescription != nil {
+		description.TTLStatus = string(ttl.TimeToLiveDescription.TimeToLiveStatus)
+		description.TTLAttribute = aws.ToString(ttl.TimeToLiveDescription.AttributeName)
+	}
+	return description, nil
+}
+
 // Salva o registro na tabela DynamoDB.
 // Se já houver registro com o mesmo id, ele será substituído.
 func (p *DynamoDB) Save(ctx context.Context, event *models.Event) error {
 	ctx, span := p.newSpan(ctx, "put-item", "")
 	defer span.End()
+	defer p.metrics.observe(ctx, "put-item", time.Now())
 	if event.Expiration == 0 {
-		event.Expiration = time.Now().Add(p.config.TTL).Unix()
+		event.Expiration = time.Now().Add(time.Duration(p.ttl.Load())).Unix()
 	}
 	item, err := attributevalue.MarshalMap(event)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to convert record to dynamodb object")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to convert record to dynamodb object, %s", err))
-		return err
+		slog.ErrorContext(ctx, "unable to convert record to dynamodb object", "error", err)
+		return newError(ErrValidation, "put-item", err)
 	}
-	_, err = p.config.Client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: &p.config.Table,
-		Item:      item,
-	})
+	out, err := p.config.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:              &p.config.Table,
+		Item:                   item,
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}, p.dynamoDBMetrics.instrument(ctx, "put-item"))
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to put item on dynamodb")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to put item on dynamodb, %s", err))
-		return err
+		slog.ErrorContext(ctx, "unable to put item on dynamodb", "error", err)
+		return dynamoDBError("put-item", err)
 	}
+	p.recordCapacity(ctx, span, "put-item", true, out.ConsumedCapacity)
 	return nil
 }
 
+// Registra a capacidade consumida por uma chamada nas métricas e no span.
+func (p *DynamoDB) recordCapacity(ctx context.Context, span trace.Span, operation string, write bool, capacity *types.ConsumedCapacity) {
+	if capacity == nil {
+		return
+	}
+	total := &consumedCapacity{}
+	total.add(ctx, p.dynamoDBMetrics, operation, write, *capacity)
+	total.setAttributes(span)
+}
+
+// Salva um lote de registros na tabela DynamoDB usando BatchWriteItem.
+// Retorna os registros que não puderam ser salvos após as novas tentativas.
+func (p *DynamoDB) SaveBatch(ctx context.Context, events []*models.Event) (failed []*models.Event, err error) {
+	ctx, span := p.newSpan(ctx, "batch-write-item", "")
+	defer span.End()
+	defer p.metrics.observe(ctx, "batch-write-item", time.Now())
+	span.SetAttributes(attribute.Int("db.operation.batch.size", len(events)))
+	capacity := &consumedCapacity{}
+	defer capacity.setAttributes(span)
+	for start := 0; start < len(events); start += dynamoDBBatchSize {
+		end := min(start+dynamoDBBatchSize, len(events))
+		chunkFailed, chunkErr := p.writeBatch(ctx, events[start:end], capacity)
+		if chunkErr != nil {
+			failed = append(failed, chunkFailed...)
+			err = errors.Join(err, chunkErr)
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to write batch on dynamodb")
+		slog.ErrorContext(ctx, "unable to write items on dynamodb", "failed", len(failed), "items", len(events), "error", err)
+		return failed, err
+	}
+	return nil, nil
+}
+
+// Grava um lote de até 25 registros, repetindo os itens não processados.
+func (p *DynamoDB) writeBatch(ctx context.Context, events []*models.Event, capacity *consumedCapacity) (failed []*models.Event, err error) {
+	// o BatchWriteItem não aceita chaves duplicadas, então prevalece o último registro
+	pending := make(map[string]*models.Event, len(events))
+	requests := make([]types.WriteRequest, 0, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		if _, ok := pending[event.Id]; ok {
+			continue
+		}
+		if event.Expiration == 0 {
+			event.Expiration = time.Now().Add(time.Duration(p.ttl.Load())).Unix()
+		}
+		item, marshalErr := attributevalue.MarshalMap(event)
+		if marshalErr != nil {
+			failed = append(failed, event)
+			err = errors.Join(err, newError(ErrValidation, "batch-write-item", marshalErr))
+			continue
+		}
+		pending[event.Id] = event
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+	}
+	for attempt := 0; len(requests) > 0 && attempt <= dynamoDBBatchRetries; attempt++ {
+		if attempt > 0 {
+			p.dynamoDBMetrics.retry(ctx, "batch-write-item", "unprocessed_items")
+			if waitErr := sleepContext(ctx, time.Duration(1<<attempt)*50*time.Millisecond); waitErr != nil {
+				err = errors.Join(err, waitErr)
+				break
+			}
+		}
+		out, writeErr := p.config.Client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems:           map[string][]types.WriteRequest{p.config.Table: requests},
+			ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+		}, p.dynamoDBMetrics.instrument(ctx, "batch-write-item"))
+		if writeErr != nil {
+			err = errors.Join(err, dynamoDBError("batch-write-item", writeErr))
+			break
+		}
+		capacity.add(ctx, p.dynamoDBMetrics, "batch-write-item", true, out.ConsumedCapacity...)
+		requests = out.UnprocessedItems[p.config.Table]
+	}
+	// os itens que restaram não foram gravados
+	for _, request := range requests {
+		if request.PutRequest == nil {
+			continue
+		}
+		if id, ok := request.PutRequest.Item["id"].(*types.AttributeValueMemberS); ok {
+			if event, ok := pending[id.Value]; ok {
+				failed = append(failed, event)
+			}
+		}
+	}
+	// os itens não processados após as novas tentativas indicam limitação de vazão
+	if len(failed) > 0 && err == nil {
+		err = newError(ErrThrottled, "batch-write-item", fmt.Errorf("%d items left unprocessed", len(failed)))
+	}
+	return failed, err
+}
+
+// Aguarda o tempo informado ou o cancelamento do contexto.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // Deleta o registro da tabela DynamoDB pelo id.
 func (p *DynamoDB) Delete(ctx context.Context, id string) (event *models.Event, err error) {
 	ctx, span := p.newSpan(ctx, "delete-item", "id = "+id)
 	defer span.End()
+	defer p.metrics.observe(ctx, "delete-item", time.Now())
 	out, err := p.config.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: &p.config.Table,
 		Key: map[string]types.AttributeValue{
 			"id": &types.AttributeValueMemberS{Value: id},
 		},
-		ReturnValues: types.ReturnValueAllOld,
-	})
+		ReturnValues:           types.ReturnValueAllOld,
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}, p.dynamoDBMetrics.instrument(ctx, "delete-item"))
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to delete item from dynamodb")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to delete item from dynamodb, %s", err))
-		return nil, err
+		slog.ErrorContext(ctx, "unable to delete item from dynamodb", "error", err)
+		return nil, dynamoDBError("delete-item", err)
 	}
+	p.recordCapacity(ctx, span, "delete-item", true, out.ConsumedCapacity)
 	if out.Attributes == nil {
 		span.AddEvent("record not found")
-		return nil, nil
+		return nil, newError(ErrNotFound, "delete-item", nil)
 	}
 	err = attributevalue.UnmarshalMap(out.Attributes, &event)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to convert dynamodb object to record")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to convert dynamodb object to record, %s", err))
+		slog.ErrorContext(ctx, "unable to convert dynamodb object to record", "error", err)
 		return nil, err
 	}
 	return event, nil
@@ -209,27 +477,30 @@ func (p *DynamoDB) Delete(ctx context.Context, id string) (event *models.Event,
 func (p *DynamoDB) Get(ctx context.Context, id string) (event *models.Event, err error) {
 	ctx, span := p.newSpan(ctx, "get-item", "id = "+id)
 	defer span.End()
+	defer p.metrics.observe(ctx, "get-item", time.Now())
 	out, err := p.config.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &p.config.Table,
 		Key: map[string]types.AttributeValue{
 			"id": &types.AttributeValueMemberS{Value: id},
 		},
-	})
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}, p.dynamoDBMetrics.instrument(ctx, "get-item"))
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to get item from dynamodb")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to get item from dynamodb, %s", err))
-		return nil, err
+		slog.ErrorContext(ctx, "unable to get item from dynamodb", "error", err)
+		return nil, dynamoDBError("get-item", err)
 	}
+	p.recordCapacity(ctx, span, "get-item", false, out.ConsumedCapacity)
 	if out.Item == nil {
 		span.AddEvent("record not found")
-		return nil, nil
+		return nil, newError(ErrNotFound, "get-item", nil)
 	}
 	err = attributevalue.UnmarshalMap(out.Item, &event)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to convert dynamodb object to record")
-		slog.ErrorContext(ctx, fmt.Sprintf("unable to convert dynamodb object to record, %s", err))
+		slog.ErrorContext(ctx, "unable to convert dynamodb object to record", "error", err)
 		return nil, err
 	}
 	return event, nil
@@ -247,29 +518,21 @@ func (p *DynamoDB) FindByDateAndReturnCode(ctx context.Context, from time.Time,
 			"date-statusCode-index"),
 	)
 	defer span.End()
-	condition := &dynamodb.QueryInput{
-		TableName: aws.String(p.config.Table),
-		IndexName: aws.String("date-statusCode-index"),
-		KeyConditionExpression: aws.String(
-			"statusCode = :statusCode AND #date BETWEEN :from AND :to",
-		),
-		ExpressionAttributeNames: map[string]string{
-			"#date": "date", // palavra reservada no DynamoDB
-		},
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":statusCode": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", statusCode)},
-			":from":       &types.AttributeValueMemberS{Value: from.Format(time.RFC3339)},
-			":to":         &types.AttributeValueMemberS{Value: to.Format(time.RFC3339)},
-		},
-	}
+	defer p.metrics.observe(ctx, "query", time.Now())
+	capacity := &consumedCapacity{}
+	defer capacity.setAttributes(span)
+	condition := p.queryByDateInput(from, to, statusCode)
 	paginator := dynamodb.NewQueryPaginator(p.config.Client, condition)
 	for paginator.HasMorePages() {
-		page, err := paginator.NextPage(ctx)
+		page, err := paginator.NextPage(ctx, p.dynamoDBMetrics.instrument(ctx, "query"))
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "unable to get next page of records from dynamodb")
-			slog.ErrorContext(ctx, fmt.Sprintf("unable to get next page of records from dynamodb, %s", err))
-			return nil, err
+			slog.ErrorContext(ctx, "unable to get next page of records from dynamodb", "error", err)
+			return nil, dynamoDBError("query", err)
+		}
+		if page.ConsumedCapacity != nil {
+			capacity.add(ctx, p.dynamoDBMetrics, "query", false, *page.ConsumedCapacity)
 		}
 		for _, item := range page.Items {
 			event := &models.Event{}
@@ -277,7 +540,7 @@ func (p *DynamoDB) FindByDateAndReturnCode(ctx context.Context, from time.Time,
 			if err != nil {
 				span.RecordError(err)
 				span.SetStatus(codes.Error, "unable to convert dynamodb object to record")
-				slog.ErrorContext(ctx, fmt.Sprintf("unable to convert dynamodb object to record, %s", err))
+				slog.ErrorContext(ctx, "unable to convert dynamodb object to record", "error", err)
 				return nil, err
 			}
 			events = append(events, event)
@@ -285,3 +548,189 @@ func (p *DynamoDB) FindByDateAndReturnCode(ctx context.Context, from time.Time,
 	}
 	return events, nil
 }
+
+// Percorre todos os registros da tabela usando segmentos paralelos do Scan.
+// A função fn pode ser chamada concorrentemente pelos segmentos e, se retornar
+// erro, a leitura de todos os segmentos é interrompida.
+func (p *DynamoDB) Scan(ctx context.Context, segments int, fn func(event *models.Event) error) error {
+	ctx, span := p.newSpan(ctx, "scan", "")
+	defer span.End()
+	defer p.metrics.observe(ctx, "scan", time.Now())
+	capacity := &consumedCapacity{}
+	defer capacity.setAttributes(span)
+	if segments < 1 {
+		segments = 1
+	}
+	span.SetAttributes(attribute.Int("aws.dynamodb.total_segments", segments))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, segments)
+	var wg sync.WaitGroup
+	for segment := 0; segment < segments; segment++ {
+		wg.Add(1)
+		go func(segment int) {
+			defer wg.Done()
+			if err := p.scanSegment(ctx, segment, segments, capacity, fn); err != nil {
+				errs[segment] = err
+				cancel()
+			}
+		}(segment)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to scan records from dynamodb")
+		slog.ErrorContext(ctx, "unable to scan records from dynamodb", "error", err)
+		return err
+	}
+	return nil
+}
+
+// Percorre um segmento do Scan.
+func (p *DynamoDB) scanSegment(ctx context.Context, segment int, segments int, capacity *consumedCapacity, fn func(event *models.Event) error) error {
+	input := &dynamodb.ScanInput{
+		TableName:              aws.String(p.config.Table),
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}
+	if segments > 1 {
+		input.Segment = aws.Int32(int32(segment))
+		input.TotalSegments = aws.Int32(int32(segments))
+	}
+	paginator := dynamodb.NewScanPaginator(p.config.Client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx, p.dynamoDBMetrics.instrument(ctx, "scan"))
+		if err != nil {
+			return dynamoDBError("scan", err)
+		}
+		if page.ConsumedCapacity != nil {
+			capacity.add(ctx, p.dynamoDBMetrics, "scan", false, *page.ConsumedCapacity)
+		}
+		for _, item := range page.Items {
+			event := &models.Event{}
+			if err := attributevalue.UnmarshalMap(item, event); err != nil {
+				return err
+			}
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Monta a consulta pelo índice de data e status code.
+func (p *DynamoDB) queryByDateInput(from time.Time, to time.Time, statusCode int) *dynamodb.QueryInput {
+	return &dynamodb.QueryInput{
+		TableName:              aws.String(p.config.Table),
+		IndexName:              aws.String("date-statusCode-index"),
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+		KeyConditionExpression: aws.String(
+			"statusCode = :statusCode AND #date BETWEEN :from AND :to",
+		),
+		ExpressionAttributeNames: map[string]string{
+			"#date": "date", // palavra reservada no DynamoDB
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":statusCode": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", statusCode)},
+			":from":       &types.AttributeValueMemberS{Value: from.Format(time.RFC3339)},
+			":to":         &types.AttributeValueMemberS{Value: to.Format(time.RFC3339)},
+		},
+	}
+}
+
+// Percorre os registros com a data entre o período especificado, entregando-os página a página.
+// Quando o status code é informado usa a consulta pelo índice, caso contrário usa o Scan com filtro pela data.
+// A leitura é interrompida se o contexto for cancelado ou se a função fn retornar erro.
+func (p *DynamoDB) StreamByDate(ctx context.Context, from time.Time, to time.Time, statusCode *int, fn func(events []*models.Event) error) error {
+	var nextPage func(ctx context.Context) ([]map[string]types.AttributeValue, error)
+	var hasMorePages func() bool
+	var span trace.Span
+	capacity := &consumedCapacity{}
+	operation := "scan"
+	if statusCode != nil {
+		operation = "query"
+		ctx, span = p.newSpan(
+			ctx,
+			"query",
+			fmt.Sprintf("statusCode = %d AND date BETWEEN %s AND %s on INDEX %s",
+				*statusCode,
+				from.Format(time.RFC3339),
+				to.Format(time.RFC3339),
+				"date-statusCode-index"),
+		)
+		paginator := dynamodb.NewQueryPaginator(p.config.Client, p.queryByDateInput(from, to, *statusCode))
+		hasMorePages = paginator.HasMorePages
+		nextPage = func(ctx context.Context) ([]map[string]types.AttributeValue, error) {
+			page, err := paginator.NextPage(ctx, p.dynamoDBMetrics.instrument(ctx, operation))
+			if err != nil {
+				return nil, dynamoDBError(operation, err)
+			}
+			if page.ConsumedCapacity != nil {
+				capacity.add(ctx, p.dynamoDBMetrics, operation, false, *page.ConsumedCapacity)
+			}
+			return page.Items, nil
+		}
+	} else {
+		ctx, span = p.newSpan(
+			ctx,
+			"scan",
+			fmt.Sprintf("date BETWEEN %s AND %s", from.Format(time.RFC3339), to.Format(time.RFC3339)),
+		)
+		paginator := dynamodb.NewScanPaginator(p.config.Client, &dynamodb.ScanInput{
+			TableName:              aws.String(p.config.Table),
+			FilterExpression:       aws.String("#date BETWEEN :from AND :to"),
+			ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+			ExpressionAttributeNames: map[string]string{
+				"#date": "date", // palavra reservada no DynamoDB
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":from": &types.AttributeValueMemberS{Value: from.Format(time.RFC3339)},
+				":to":   &types.AttributeValueMemberS{Value: to.Format(time.RFC3339)},
+			},
+		})
+		hasMorePages = paginator.HasMorePages
+		nextPage = func(ctx context.Context) ([]map[string]types.AttributeValue, error) {
+			page, err := paginator.NextPage(ctx, p.dynamoDBMetrics.instrument(ctx, operation))
+			if err != nil {
+				return nil, dynamoDBError(operation, err)
+			}
+			if page.ConsumedCapacity != nil {
+				capacity.add(ctx, p.dynamoDBMetrics, operation, false, *page.ConsumedCapacity)
+			}
+			return page.Items, nil
+		}
+	}
+	defer span.End()
+	defer p.metrics.observe(ctx, operation, time.Now())
+	defer capacity.setAttributes(span)
+	pages := 0
+	for hasMorePages() {
+		items, err := nextPage(ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "unable to get next page of records from dynamodb")
+			slog.ErrorContext(ctx, "unable to get next page of records from dynamodb", "error", err)
+			return err
+		}
+		pages++
+		events := make([]*models.Event, 0, len(items))
+		if err := attributevalue.UnmarshalListOfMaps(items, &events); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "unable to convert dynamodb object to record")
+			slog.ErrorContext(ctx, "unable to convert dynamodb object to record", "error", err)
+			return err
+		}
+		if len(events) == 0 {
+			continue
+		}
+		if err := fn(events); err != nil {
+			span.AddEvent(
+				"stream interrupted",
+				trace.WithAttributes(attribute.String("error", err.Error())),
+			)
+			return err
+		}
+	}
+	span.SetAttributes(attribute.Int("db.response.pages", pages))
+	return nil
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// Erros dos repositórios, comparados com errors.Is independentemente do banco de dados.
+var (
+	// o registro não existe ou expirou
+	ErrNotFound = errors.New("record not found")
+	// o registro foi alterado por outra operação; as gravações atuais não usam
+	// condições, então apenas conflitos com transações de outros clientes o produzem
+	ErrConflict = errors.New("record conflict")
+	// o banco de dados limitou a vazão das operações
+	ErrThrottled = errors.New("repository throttled")
+	// o banco de dados está indisponível ou não respondeu a tempo
+	ErrUnavailable = errors.New("repository unavailable")
+	// o registro foi rejeitado pelo banco de dados
+	ErrValidation = errors.New("invalid record")
+	// a operação foi rejeitada pelo circuit breaker aberto, sempre junto com ErrUnavailable
+	ErrCircuitOpen = errors.New("circuit breaker open")
+)
+
+// Tempo sugerido aos clientes antes de repetir uma operação limitada pelo banco de dados.
+const throttledRetryAfter = time.Second
+
+// Erro de uma operação do repositório com o tipo (um dos erros Err*) e a causa original.
+// A mensagem inclui a causa e não deve ser exposta aos clientes.
+type Error struct {
+	// tipo do erro, comparado com errors.Is
+	Kind error
+	// operação do repositório que falhou
+	Operation string
+	// tempo sugerido antes de repetir a operação, zero se não houver
+	RetryAfter time.Duration
+	// erro original do banco de dados
+	Err error
+}
+
+// Retorna a mensagem do erro com a causa original.
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %s", e.Operation, e.Kind)
+	}
+	return fmt.Sprintf("%s: %s, %s", e.Operation, e.Kind, e.Err)
+}
+
+// Permite comparar o erro com o tipo e com a causa original.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Cria o erro do tipo informado para a operação.
+func newError(kind error, operation string, err error) *Error {
+	e := &Error{Kind: kind, Operation: operation, Err: err}
+	if kind == ErrThrottled {
+		e.RetryAfter = throttledRetryAfter
+	}
+	return e
+}
+
+// Retorna o tempo sugerido antes de repetir a operação que falhou com o erro,
+// ou zero se não houver.
+func RetryAfter(err error) time.Duration {
+	var repositoryErr *Error
+	if errors.As(err, &repositoryErr) {
+		return repositoryErr.RetryAfter
+	}
+	return 0
+}
+
+// Classifica o erro de uma chamada ao DynamoDB nos erros do repositório.
+// Erros não reconhecidos, o cancelamento e o tempo esgotado do contexto são retornados
+// sem alteração: o contexto pode ser o do chamador, e só a camada de resiliência sabe se
+// o tempo esgotado foi o da tentativa (ver Resilience).
+// ConditionalCheckFailedException fica reservado para gravações com ConditionExpression,
+// que hoje não existem.
+func dynamoDBError(operation string, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "ConditionalCheckFailedException", "TransactionConflictException":
+			return newError(ErrConflict, operation, err)
+		case "ValidationException", "ItemCollectionSizeLimitExceededException":
+			return newError(ErrValidation, operation, err)
+		case "ResourceNotFoundException", "InternalServerError":
+			return newError(ErrUnavailable, operation, err)
+		}
+	}
+	if retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary {
+		return newError(ErrThrottled, operation, err)
+	}
+	var responseErr *smithyhttp.ResponseError
+	if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() >= 500 {
+		return newError(ErrUnavailable, operation, err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return newError(ErrUnavailable, operation, err)
+	}
+	return err
+}
@@ -2,13 +2,16 @@ package repositories
 
 import (
 	"api/models"
+	"api/telemetry"
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -22,19 +25,53 @@ type MemoryDBConfig struct {
 type MemoryDB struct {
 	// banco de dados em memória
 	db map[string]*models.Event
+	// controla o acesso concorrente ao banco de dados
+	mu sync.RWMutex
 	// configuração do repositório
 	config *MemoryDBConfig
+	// tempo de expiração dos registros, alterável sem reiniciar a aplicação
+	ttl atomic.Int64
 	// configura o tracer
 	tracer trace.Tracer
+	// métricas das operações
+	metrics *repositoryMetrics
+	// registro da métrica da quantidade de itens
+	itemsRegistration metric.Registration
 }
 
 // Cria uma nova instância do repositório de memória.
 func NewMemoryDB(config *MemoryDBConfig) *MemoryDB {
-	return &MemoryDB{
-		db:     make(map[string]*models.Event),
-		config: config,
-		tracer: otel.Tracer("memorydb.repository"),
+	p := &MemoryDB{
+		db:      make(map[string]*models.Event),
+		config:  config,
+		tracer:  otel.Tracer("memorydb.repository"),
+		metrics: newRepositoryMetrics("memorydb"),
 	}
+	p.ttl.Store(int64(config.TTL))
+	// configura a métrica da quantidade de itens, incluindo os expirados ainda não removidos
+	meter := otel.Meter("repository.metrics")
+	if gauge, err := meter.Int64ObservableGauge("custom.memorydb.items",
+		metric.WithDescription("The number of items stored in memory"),
+		metric.WithUnit("{items}")); err == nil {
+		registration, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+			p.mu.RLock()
+			defer p.mu.RUnlock()
+			o.ObserveInt64(gauge, int64(len(p.db)))
+			return nil
+		}, gauge)
+		if err != nil {
+			panic(err)
+		}
+		p.itemsRegistration = registration
+	} else {
+		panic(err)
+	}
+	return p
+}
+
+// Altera o tempo de expiração dos novos registros.
+func (p *MemoryDB) SetTTL(ttl time.Duration) {
+	p.ttl.Store(int64(ttl))
 }
 
 // Cria um span contextualizado para o banco de dados de memória.
@@ -43,14 +80,10 @@ func (p *MemoryDB) newSpan(ctx context.Context, operation string, statement stri
 		ctx,
 		operation,
 		trace.WithSpanKind(trace.SpanKindClient),
-		trace.WithAttributes(
-			attribute.String("db.system", "memorydb"),
-			attribute.String("db.name", "events"),
-			attribute.String("db.operation", operation),
-		),
+		trace.WithAttributes(telemetry.DBAttributes("memorydb", "events", operation)...),
 	)
 	if statement != "" {
-		span.SetAttributes(attribute.String("db.statement", statement))
+		span.SetAttributes(telemetry.DBQueryText(statement)...)
 	}
 	return ctx, span
 }
@@ -60,29 +93,58 @@ func (p *MemoryDB) Create(ctx context.Context) error {
 	return nil
 }
 
+// Libera os recursos do repositório, encerrando a métrica da quantidade de itens.
+func (p *MemoryDB) Close(ctx context.Context) error {
+	return p.itemsRegistration.Unregister()
+}
+
+// Verifica o repositório (sempre disponível no caso do MemoryDB).
+func (p *MemoryDB) Ping(ctx context.Context) error {
+	return nil
+}
+
 // Salva o registro na memória.
 // Se já houver registro com o mesmo id, ele será substituído.
 func (p *MemoryDB) Save(ctx context.Context, event *models.Event) error {
 	ctx, span := p.newSpan(ctx, "save", "")
 	defer span.End()
-	if event.Expiration == 0 && p.config.TTL > 0 {
-		event.Expiration = time.Now().Add(p.config.TTL).Unix()
+	defer p.metrics.observe(ctx, "save", time.Now())
+	if ttl := time.Duration(p.ttl.Load()); event.Expiration == 0 && ttl > 0 {
+		event.Expiration = time.Now().Add(ttl).Unix()
 	}
 	if event.Id == "" {
 		event.Id = uuid.New().String()
 	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.db[event.Id] = event
 	return nil
 }
 
+// Salva um lote de registros na memória.
+func (p *MemoryDB) SaveBatch(ctx context.Context, events []*models.Event) (failed []*models.Event, err error) {
+	ctx, span := p.newSpan(ctx, "save-batch", "")
+	defer span.End()
+	defer p.metrics.observe(ctx, "save-batch", time.Now())
+	for _, event := range events {
+		if err := p.Save(ctx, event); err != nil {
+			return events, err
+		}
+	}
+	return nil, nil
+}
+
 // Deleta o registro da memória pelo id.
 func (p *MemoryDB) Delete(ctx context.Context, id string) (event *models.Event, err error) {
 	ctx, span := p.newSpan(ctx, "delete", "id = "+id)
 	defer span.End()
+	defer p.metrics.observe(ctx, "delete", time.Now())
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	event, ok := p.db[id]
 	if !ok {
 		span.AddEvent("record not found")
-		return nil, nil
+		return nil, newError(ErrNotFound, "delete", nil)
 	}
 	delete(p.db, id)
 	return event, nil
@@ -92,14 +154,18 @@ func (p *MemoryDB) Delete(ctx context.Context, id string) (event *models.Event,
 func (p *MemoryDB) Get(ctx context.Context, id string) (event *models.Event, err error) {
 	ctx, span := p.newSpan(ctx, "get", "id = "+id)
 	defer span.End()
+	defer p.metrics.observe(ctx, "get", time.Now())
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	event, ok := p.db[id]
 	if !ok {
 		span.AddEvent("record not found")
-		return nil, nil
+		return nil, newError(ErrNotFound, "get", nil)
 	}
 	if event.Expiration != 0 && time.Unix(event.Expiration, 0).Before(time.Now()) {
 		delete(p.db, id)
-		return nil, nil
+		span.AddEvent("record expired")
+		return nil, newError(ErrNotFound, "get", nil)
 	}
 	return event, nil
 }
@@ -108,6 +174,9 @@ func (p *MemoryDB) Get(ctx context.Context, id string) (event *models.Event, err
 func (p *MemoryDB) FindByDateAndReturnCode(ctx context.Context, from time.Time, to time.Time, statusCode int) (events []*models.Event, err error) {
 	ctx, span := p.newSpan(ctx, "query", fmt.Sprintf("from = %s to = %s statusCode = %d", from.Format(time.RFC3339), to.Format(time.RFC3339), statusCode))
 	defer span.End()
+	defer p.metrics.observe(ctx, "query", time.Now())
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	expired := make([]string, 0)
 	for k, v := range p.db {
 		if v.Expiration != 0 && time.Unix(v.Expiration, 0).Before(time.Now()) {
@@ -123,3 +192,52 @@ func (p *MemoryDB) FindByDateAndReturnCode(ctx context.Context, from time.Time,
 	}
 	return events, nil
 }
+
+// Percorre todos os registros da memória.
+// O parâmetro segments é ignorado, pois a leitura é sequencial.
+func (p *MemoryDB) Scan(ctx context.Context, segments int, fn func(event *models.Event) error) error {
+	ctx, span := p.newSpan(ctx, "scan", "")
+	defer span.End()
+	defer p.metrics.observe(ctx, "scan", time.Now())
+	p.mu.RLock()
+	events := make([]*models.Event, 0, len(p.db))
+	for _, v := range p.db {
+		if v.Expiration != 0 && time.Unix(v.Expiration, 0).Before(time.Now()) {
+			continue
+		}
+		events = append(events, v)
+	}
+	p.mu.RUnlock()
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Percorre os registros com a data entre o período especificado em uma única página.
+// Quando o status code não é informado, registros de qualquer status code são retornados.
+func (p *MemoryDB) StreamByDate(ctx context.Context, from time.Time, to time.Time, statusCode *int, fn func(events []*models.Event) error) error {
+	ctx, span := p.newSpan(ctx, "stream", fmt.Sprintf("from = %s to = %s", from.Format(time.RFC3339), to.Format(time.RFC3339)))
+	defer span.End()
+	defer p.metrics.observe(ctx, "stream", time.Now())
+	p.mu.RLock()
+	events := make([]*models.Event, 0)
+	for _, v := range p.db {
+		if v.Expiration != 0 && time.Unix(v.Expiration, 0).Before(time.Now()) {
+			continue
+		}
+		if statusCode != nil && v.StatusCode != *statusCode {
+			continue
+		}
+		if (v.Date.After(from) || v.Date.Equal(from)) && (v.Date.Before(to) || v.Date.Equal(to)) {
+			events = append(events, v)
+		}
+	}
+	p.mu.RUnlock()
+	if len(events) == 0 {
+		return nil
+	}
+	return fn(events)
+}
@@ -0,0 +1,195 @@
+package repositories
+
+import (
+	"api/telemetry"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Métricas comuns aos repositórios.
+type repositoryMetrics struct {
+	// sistema de banco de dados usado nos atributos
+	system string
+	// duração das operações do repositório
+	operationHistogram metric.Float64Histogram
+}
+
+// Cria as métricas comuns do repositório do sistema informado.
+func newRepositoryMetrics(system string) *repositoryMetrics {
+	m := &repositoryMetrics{system: system}
+	meter := otel.Meter("repository.metrics")
+	if histogram, err := meter.Float64Histogram("custom.repository.operation.duration",
+		metric.WithDescription("The duration of repository operations"),
+		metric.WithUnit("ms")); err == nil {
+		m.operationHistogram = histogram
+	} else {
+		panic(err)
+	}
+	return m
+}
+
+// Registra a duração da operação iniciada em start.
+// Deve ser usada com defer no início da operação.
+func (m *repositoryMetrics) observe(ctx context.Context, operation string, start time.Time) {
+	m.operationHistogram.Record(ctx, float64(time.Since(start).Microseconds())/1000, metric.WithAttributes(
+		telemetry.DBMetricAttributes(m.system, operation)...,
+	))
+}
+
+// Métricas específicas do DynamoDB.
+type dynamoDBMetrics struct {
+	// unidades de capacidade consumidas por chamada
+	readCapacityHistogram  metric.Float64Histogram
+	writeCapacityHistogram metric.Float64Histogram
+	// novas tentativas e erros de limitação de vazão
+	retryCounter    metric.Int64Counter
+	throttleCounter metric.Int64Counter
+}
+
+// Cria as métricas específicas do DynamoDB.
+func newDynamoDBMetrics() *dynamoDBMetrics {
+	m := &dynamoDBMetrics{}
+	meter := otel.Meter("repository.metrics")
+	if histogram, err := meter.Float64Histogram("custom.dynamodb.consumed.read_capacity",
+		metric.WithDescription("The read capacity units consumed by DynamoDB calls"),
+		metric.WithUnit("{capacity_unit}")); err == nil {
+		m.readCapacityHistogram = histogram
+	} else {
+		panic(err)
+	}
+	if histogram, err := meter.Float64Histogram("custom.dynamodb.consumed.write_capacity",
+		metric.WithDescription("The write capacity units consumed by DynamoDB calls"),
+		metric.WithUnit("{capacity_unit}")); err == nil {
+		m.writeCapacityHistogram = histogram
+	} else {
+		panic(err)
+	}
+	if counter, err := meter.Int64Counter("custom.dynamodb.retries.total",
+		metric.WithDescription("The number of DynamoDB call retries"),
+		metric.WithUnit("{retries}")); err == nil {
+		m.retryCounter = counter
+	} else {
+		panic(err)
+	}
+	if counter, err := meter.Int64Counter("custom.dynamodb.throttles.total",
+		metric.WithDescription("The number of DynamoDB throttling errors"),
+		metric.WithUnit("{errors}")); err == nil {
+		m.throttleCounter = counter
+	} else {
+		panic(err)
+	}
+	return m
+}
+
+// Registra uma nova tentativa da operação pelo motivo informado.
+func (m *dynamoDBMetrics) retry(ctx context.Context, operation string, reason string) {
+	m.retryCounter.Add(ctx, 1, metric.WithAttributes(
+		append(telemetry.DBMetricAttributes(dynamoDBSystem, operation), attribute.String("reason", reason))...,
+	))
+}
+
+// Retorna a opção da chamada ao DynamoDB que registra as novas tentativas
+// e os erros de limitação de vazão do SDK. Nas operações repetidas pela camada
+// de resiliência o SDK faz uma única tentativa.
+func (m *dynamoDBMetrics) instrument(ctx context.Context, operation string) func(*dynamodb.Options) {
+	return func(o *dynamodb.Options) {
+		if isRetriedAttempt(ctx) {
+			o.RetryMaxAttempts = 1
+		}
+		if o.Retryer == nil {
+			return
+		}
+		o.Retryer = &metricsRetryer{Retryer: o.Retryer, ctx: ctx, operation: operation, metrics: m}
+	}
+}
+
+// Soma a capacidade consumida pelas chamadas de uma operação, que podem ser
+// concorrentes, e a registra nos histogramas.
+type consumedCapacity struct {
+	mu    sync.Mutex
+	read  float64
+	write float64
+}
+
+// Registra a capacidade consumida por uma chamada da operação.
+// Sem o detalhamento por leitura e escrita, as unidades são atribuídas pelo tipo da operação.
+func (c *consumedCapacity) add(ctx context.Context, m *dynamoDBMetrics, operation string, write bool, capacities ...types.ConsumedCapacity) {
+	var read, written float64
+	for _, capacity := range capacities {
+		switch {
+		case capacity.ReadCapacityUnits != nil || capacity.WriteCapacityUnits != nil:
+			read += aws.ToFloat64(capacity.ReadCapacityUnits)
+			written += aws.ToFloat64(capacity.WriteCapacityUnits)
+		case write:
+			written += aws.ToFloat64(capacity.CapacityUnits)
+		default:
+			read += aws.ToFloat64(capacity.CapacityUnits)
+		}
+	}
+	attrs := metric.WithAttributes(telemetry.DBMetricAttributes(dynamoDBSystem, operation)...)
+	if read > 0 {
+		m.readCapacityHistogram.Record(ctx, read, attrs)
+	}
+	if written > 0 {
+		m.writeCapacityHistogram.Record(ctx, written, attrs)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.read += read
+	c.write += written
+}
+
+// Adiciona a capacidade total consumida aos atributos do span.
+func (c *consumedCapacity) setAttributes(span trace.Span) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.read > 0 {
+		span.SetAttributes(attribute.Float64("aws.dynamodb.consumed_read_capacity_units", c.read))
+	}
+	if c.write > 0 {
+		span.SetAttributes(attribute.Float64("aws.dynamodb.consumed_write_capacity_units", c.write))
+	}
+}
+
+// Retryer do SDK que registra as tentativas de uma chamada ao DynamoDB.
+type metricsRetryer struct {
+	aws.Retryer
+	// contexto e operação da chamada, usados nas métricas
+	ctx       context.Context
+	operation string
+	metrics   *dynamoDBMetrics
+}
+
+// Verifica se o erro da tentativa permite nova tentativa, registrando a limitação de vazão.
+// É chamado para toda tentativa que falhou.
+func (r *metricsRetryer) IsErrorRetryable(err error) bool {
+	if retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary {
+		r.metrics.throttleCounter.Add(r.ctx, 1, metric.WithAttributes(telemetry.DBMetricAttributes(dynamoDBSystem, r.operation)...))
+		trace.SpanFromContext(r.ctx).AddEvent("throttled", trace.WithAttributes(attribute.String("error", err.Error())))
+	}
+	return r.Retryer.IsErrorRetryable(err)
+}
+
+// Retorna o intervalo até a nova tentativa, registrando-a.
+func (r *metricsRetryer) RetryDelay(attempt int, err error) (time.Duration, error) {
+	r.metrics.retry(r.ctx, r.operation, "sdk")
+	return r.Retryer.RetryDelay(attempt, err)
+}
+
+// Retorna o token da tentativa, usando a versão com contexto quando disponível.
+func (r *metricsRetryer) GetAttemptToken(ctx context.Context) (func(error) error, error) {
+	if v2, ok := r.Retryer.(aws.RetryerV2); ok {
+		return v2.GetAttemptToken(ctx)
+	}
+	return r.Retryer.GetInitialToken(), nil
+}
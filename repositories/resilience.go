@@ -0,0 +1,399 @@
+package repositories
+
+import (
+	"api/interfaces"
+	"api/models"
+	"api/telemetry"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Estados do circuit breaker.
+const (
+	// as operações são executadas normalmente
+	CircuitClosed = "closed"
+	// as operações falham imediatamente com ErrCircuitOpen
+	CircuitOpen = "open"
+	// uma operação de teste é executada para decidir se o circuito fecha
+	CircuitHalfOpen = "half_open"
+)
+
+// Define a configuração da camada de resiliência do repositório.
+type ResilienceConfig struct {
+	// repositório de dados decorado
+	Repository interfaces.Repository
+	// tempo limite de cada tentativa das operações de um registro e das consultas, zero desativa
+	Timeout time.Duration
+	// tempo limite da gravação em lote, zero desativa
+	BatchTimeout time.Duration
+	// quantidade total de tentativas para erros temporários, 1 desativa as novas tentativas
+	MaxAttempts int
+	// intervalo base e máximo entre as tentativas, com backoff exponencial e jitter
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// falhas consecutivas que abrem o circuito, zero desativa o circuit breaker
+	FailureThreshold int
+	// tempo que o circuito permanece aberto antes da operação de teste
+	OpenDuration time.Duration
+}
+
+// Define a estrutura da camada de resiliência do repositório: tempo limite por operação,
+// novas tentativas com jitter para erros temporários (ErrThrottled e ErrUnavailable) e
+// circuit breaker. Com o circuito aberto as operações falham imediatamente com
+// ErrUnavailable e ErrCircuitOpen, sem acessar o banco de dados.
+type Resilience struct {
+	interfaces.Repository
+	// configuração da camada de resiliência
+	config *ResilienceConfig
+	// sistema de banco de dados usado nos atributos das métricas
+	system string
+	// estado do circuit breaker
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+	// métricas da camada de resiliência
+	retryCounter      metric.Int64Counter
+	rejectedCounter   metric.Int64Counter
+	transitionCounter metric.Int64Counter
+	stateRegistration metric.Registration
+}
+
+// Cria a camada de resiliência sobre o repositório informado.
+func NewResilience(config *ResilienceConfig) *Resilience {
+	p := &Resilience{
+		Repository: config.Repository,
+		config:     config,
+		system:     repositorySystem(config.Repository),
+		state:      CircuitClosed,
+	}
+	meter := otel.Meter("repository.metrics")
+	if counter, err := meter.Int64Counter("custom.repository.retries.total",
+		metric.WithDescription("The number of repository operations retried after a transient error"),
+		metric.WithUnit("{retries}")); err == nil {
+		p.retryCounter = counter
+	} else {
+		panic(err)
+	}
+	if counter, err := meter.Int64Counter("custom.repository.circuit.rejected.total",
+		metric.WithDescription("The number of repository operations rejected by the open circuit breaker"),
+		metric.WithUnit("{operations}")); err == nil {
+		p.rejectedCounter = counter
+	} else {
+		panic(err)
+	}
+	if counter, err := meter.Int64Counter("custom.repository.circuit.transitions.total",
+		metric.WithDescription("The number of circuit breaker state changes"),
+		metric.WithUnit("{transitions}")); err == nil {
+		p.transitionCounter = counter
+	} else {
+		panic(err)
+	}
+	if gauge, err := meter.Int64ObservableGauge("custom.repository.circuit.state",
+		metric.WithDescription("The circuit breaker state: 0 closed, 1 half open, 2 open"),
+		metric.WithUnit("{state}")); err == nil {
+		registration, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+			state := p.State()
+			o.ObserveInt64(gauge, circuitStateValue(state), metric.WithAttributes(attribute.String("state", state)))
+			return nil
+		}, gauge)
+		if err != nil {
+			panic(err)
+		}
+		p.stateRegistration = registration
+	} else {
+		panic(err)
+	}
+	return p
+}
+
+// Retorna o sistema de banco de dados do repositório, usado nos atributos das métricas.
+func repositorySystem(repository interfaces.Repository) string {
+	switch repository.(type) {
+	case *DynamoDB:
+		return dynamoDBSystem
+	case *MemoryDB:
+		return "memorydb"
+	default:
+		return "other"
+	}
+}
+
+// Retorna o valor numérico do estado usado na métrica.
+func circuitStateValue(state string) int64 {
+	switch state {
+	case CircuitHalfOpen:
+		return 1
+	case CircuitOpen:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Libera os recursos da camada de resiliência e do repositório.
+func (p *Resilience) Close(ctx context.Context) error {
+	return errors.Join(p.stateRegistration.Unregister(), p.Repository.Close(ctx))
+}
+
+// Retorna o estado atual do circuit breaker.
+func (p *Resilience) State() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == CircuitOpen && time.Since(p.openedAt) >= p.config.OpenDuration {
+		return CircuitHalfOpen
+	}
+	return p.state
+}
+
+// Verificação de saúde que falha enquanto o circuito não estiver fechado.
+func (p *Resilience) Check(ctx context.Context) error {
+	if state := p.State(); state != CircuitClosed {
+		return fmt.Errorf("circuit breaker is %s", state)
+	}
+	return nil
+}
+
+// Salva o registro.
+func (p *Resilience) Save(ctx context.Context, event *models.Event) error {
+	return p.execute(ctx, "save", p.config.Timeout, p.config.MaxAttempts, func(ctx context.Context) error {
+		return p.Repository.Save(ctx, event)
+	})
+}
+
+// Salva os registros em lote, sem novas tentativas, já feitas pelo repositório para os itens não processados.
+func (p *Resilience) SaveBatch(ctx context.Context, events []*models.Event) (failed []*models.Event, err error) {
+	err = p.execute(ctx, "save-batch", p.config.BatchTimeout, 1, func(ctx context.Context) error {
+		failed, err = p.Repository.SaveBatch(ctx, events)
+		return err
+	})
+	if err != nil && failed == nil {
+		failed = events
+	}
+	return failed, err
+}
+
+// Remove o registro.
+func (p *Resilience) Delete(ctx context.Context, id string) (event *models.Event, err error) {
+	err = p.execute(ctx, "delete", p.config.Timeout, p.config.MaxAttempts, func(ctx context.Context) error {
+		event, err = p.Repository.Delete(ctx, id)
+		return err
+	})
+	return event, err
+}
+
+// Retorna o registro pelo id.
+func (p *Resilience) Get(ctx context.Context, id string) (event *models.Event, err error) {
+	err = p.execute(ctx, "get", p.config.Timeout, p.config.MaxAttempts, func(ctx context.Context) error {
+		event, err = p.Repository.Get(ctx, id)
+		return err
+	})
+	return event, err
+}
+
+// Consulta os registros pelo período e status code.
+func (p *Resilience) FindByDateAndReturnCode(ctx context.Context, from time.Time, to time.Time, statusCode int) (events []*models.Event, err error) {
+	err = p.execute(ctx, "find", p.config.Timeout, p.config.MaxAttempts, func(ctx context.Context) error {
+		events, err = p.Repository.FindByDateAndReturnCode(ctx, from, to, statusCode)
+		return err
+	})
+	return events, err
+}
+
+// Consulta os registros em páginas, sem tempo limite nem novas tentativas, pois as
+// páginas já entregues não podem ser repetidas.
+func (p *Resilience) StreamByDate(ctx context.Context, from time.Time, to time.Time, statusCode *int, fn func(events []*models.Event) error) error {
+	return p.execute(ctx, "stream", 0, 1, func(ctx context.Context) error {
+		return p.Repository.StreamByDate(ctx, from, to, statusCode, fn)
+	})
+}
+
+// Percorre todos os registros, sem tempo limite nem novas tentativas.
+func (p *Resilience) Scan(ctx context.Context, segments int, fn func(event *models.Event) error) error {
+	return p.execute(ctx, "scan", 0, 1, func(ctx context.Context) error {
+		return p.Repository.Scan(ctx, segments, fn)
+	})
+}
+
+// Chave do contexto das tentativas repetidas pela camada de resiliência.
+type retriedAttemptKey struct{}
+
+// Indica se a chamada faz parte de uma tentativa repetida pela camada de resiliência;
+// nesse caso o repositório não deve fazer novas tentativas por conta própria, para que
+// as tentativas não se multipliquem nem atrasem a abertura do circuito.
+func isRetriedAttempt(ctx context.Context) bool {
+	return ctx.Value(retriedAttemptKey{}) != nil
+}
+
+// Executa a operação com o circuit breaker, o tempo limite de cada tentativa e as
+// novas tentativas para erros temporários.
+func (p *Resilience) execute(ctx context.Context, operation string, timeout time.Duration, attempts int, fn func(ctx context.Context) error) error {
+	span := trace.SpanFromContext(ctx)
+	if attempts > 1 {
+		ctx = context.WithValue(ctx, retriedAttemptKey{}, true)
+	}
+	var err error
+	for attempt := 1; ; attempt++ {
+		if retryAfter, ok := p.allow(); !ok {
+			p.rejectedCounter.Add(ctx, 1, metric.WithAttributes(telemetry.DBMetricAttributes(p.system, operation)...))
+			span.AddEvent("circuit open", trace.WithAttributes(attribute.String("operation", operation)))
+			if err != nil {
+				return err
+			}
+			return &Error{Kind: ErrUnavailable, Operation: operation, RetryAfter: retryAfter, Err: ErrCircuitOpen}
+		}
+		err = p.attempt(ctx, operation, timeout, fn)
+		p.record(operation, err)
+		if err == nil || attempt >= attempts || !isTransient(err) || ctx.Err() != nil {
+			return err
+		}
+		delay := p.backoff(attempt)
+		p.retryCounter.Add(ctx, 1, metric.WithAttributes(telemetry.DBMetricAttributes(p.system, operation)...))
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.String("operation", operation),
+			attribute.Int("attempt", attempt+1),
+			attribute.Int64("delay_ms", delay.Milliseconds()),
+			attribute.String("error", err.Error()),
+		))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Executa uma tentativa com o tempo limite informado.
+// O tempo esgotado da tentativa, sem o cancelamento do chamador, é tratado como ErrUnavailable.
+func (p *Resilience) attempt(ctx context.Context, operation string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := fn(attemptCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && !errors.Is(err, ErrUnavailable) {
+		return newError(ErrUnavailable, operation, err)
+	}
+	return err
+}
+
+// Indica se a operação foi interrompida pelo contexto do chamador, cancelado ou com o
+// tempo esgotado, o que não diz nada sobre o banco de dados. O tempo esgotado da
+// tentativa é convertido em ErrUnavailable por attempt e não entra aqui.
+func callerCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) ||
+		(errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrUnavailable))
+}
+
+// Indica se o erro é temporário, permitindo nova tentativa e contando como falha no circuit breaker.
+func isTransient(err error) bool {
+	return (errors.Is(err, ErrThrottled) || errors.Is(err, ErrUnavailable)) && !errors.Is(err, ErrCircuitOpen)
+}
+
+// Retorna o intervalo antes da nova tentativa: backoff exponencial limitado a MaxDelay com jitter completo.
+func (p *Resilience) backoff(attempt int) time.Duration {
+	delay := p.config.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.config.MaxDelay {
+		delay = p.config.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay + 1)
+}
+
+// Verifica se o circuito permite a operação, retornando o tempo até a próxima
+// operação de teste quando ela for rejeitada. Com o circuito meio aberto apenas
+// uma operação de teste é executada por vez.
+func (p *Resilience) allow() (time.Duration, bool) {
+	if p.config.FailureThreshold <= 0 {
+		return 0, true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch p.state {
+	case CircuitOpen:
+		if remaining := p.config.OpenDuration - time.Since(p.openedAt); remaining > 0 {
+			return remaining, false
+		}
+		p.transition(CircuitHalfOpen)
+		p.probing = true
+		return 0, true
+	case CircuitHalfOpen:
+		if p.probing {
+			return throttledRetryAfter, false
+		}
+		p.probing = true
+		return 0, true
+	default:
+		return 0, true
+	}
+}
+
+// Registra o resultado da operação no circuit breaker. Apenas os erros temporários
+// contam como falha, os demais mostram que o banco de dados respondeu e o
+// cancelamento ou o tempo esgotado do chamador não alteram o estado.
+func (p *Resilience) record(operation string, err error) {
+	if p.config.FailureThreshold <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	transient := isTransient(err)
+	if p.state == CircuitHalfOpen {
+		// se o teste foi cancelado, a próxima operação faz um novo teste
+		p.probing = false
+		switch {
+		case callerCanceled(err):
+		case transient:
+			p.open(operation, err)
+		default:
+			p.failures = 0
+			p.transition(CircuitClosed)
+		}
+		return
+	}
+	if callerCanceled(err) {
+		return
+	}
+	if !transient {
+		p.failures = 0
+		return
+	}
+	p.failures++
+	if p.state == CircuitClosed && p.failures >= p.config.FailureThreshold {
+		p.open(operation, err)
+	}
+}
+
+// Abre o circuito após a falha da operação. Deve ser chamado com o mutex bloqueado.
+func (p *Resilience) open(operation string, err error) {
+	p.openedAt = time.Now()
+	p.failures = 0
+	p.transition(CircuitOpen)
+	slog.Warn("circuit breaker opened", "operation", operation, "open_duration", p.config.OpenDuration.String(), "error", err)
+}
+
+// Altera o estado do circuito, registrando a transição. Deve ser chamado com o mutex bloqueado.
+func (p *Resilience) transition(state string) {
+	if p.state == state {
+		return
+	}
+	slog.Info("circuit breaker state changed", "from", p.state, "to", state)
+	p.state = state
+	p.transitionCounter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("state", state)))
+}
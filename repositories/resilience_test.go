@@ -0,0 +1,280 @@
+package repositories
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Retorna os erros informados em ordem, um por consulta, e sucesso após o último.
+func getResults(results ...error) func(ctx context.Context, id string) (*models.Event, error) {
+	var mu sync.Mutex
+	return func(ctx context.Context, id string) (*models.Event, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(results) == 0 {
+			return getEvent(ctx, id)
+		}
+		err := results[0]
+		results = results[1:]
+		if err != nil {
+			return nil, err
+		}
+		return getEvent(ctx, id)
+	}
+}
+
+// Aguarda o fim do contexto da consulta e retorna o erro dele.
+func getBlocked(ctx context.Context, id string) (*models.Event, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestResilienceCircuitBreaker(t *testing.T) {
+	const openDuration = 20 * time.Millisecond
+	unavailable := newError(ErrUnavailable, "get", nil)
+	throttled := newError(ErrThrottled, "get", nil)
+	notFound := newError(ErrNotFound, "get", nil)
+	type step struct {
+		// espera antes da operação
+		wait time.Duration
+		// resultado da consulta ao repositório, ignorado quando o circuito rejeita a operação
+		result error
+		// erro esperado da operação e se ela foi rejeitada sem consultar o repositório
+		wantErr  error
+		rejected bool
+		// estado do circuito após a operação
+		wantState string
+	}
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []step
+	}{
+		{name: "opens after consecutive failures", threshold: 2, steps: []step{
+			{result: unavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+			{result: throttled, wantErr: ErrThrottled, wantState: CircuitOpen},
+			{wantErr: ErrCircuitOpen, rejected: true, wantState: CircuitOpen},
+		}},
+		{name: "non transient error resets the failures", threshold: 2, steps: []step{
+			{result: unavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+			{result: notFound, wantErr: ErrNotFound, wantState: CircuitClosed},
+			{result: unavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+			{wantState: CircuitClosed},
+		}},
+		{name: "caller cancellation does not count", threshold: 1, steps: []step{
+			{result: context.Canceled, wantErr: context.Canceled, wantState: CircuitClosed},
+			{result: context.DeadlineExceeded, wantErr: context.DeadlineExceeded, wantState: CircuitClosed},
+		}},
+		{name: "successful probe closes the circuit", threshold: 1, steps: []step{
+			{result: unavailable, wantErr: ErrUnavailable, wantState: CircuitOpen},
+			{wait: openDuration, wantState: CircuitClosed},
+			{wantState: CircuitClosed},
+		}},
+		{name: "non transient probe closes the circuit", threshold: 1, steps: []step{
+			{result: unavailable, wantErr: ErrUnavailable, wantState: CircuitOpen},
+			{wait: openDuration, result: notFound, wantErr: ErrNotFound, wantState: CircuitClosed},
+		}},
+		{name: "failed probe reopens the circuit", threshold: 1, steps: []step{
+			{result: unavailable, wantErr: ErrUnavailable, wantState: CircuitOpen},
+			{wait: openDuration, result: unavailable, wantErr: ErrUnavailable, wantState: CircuitOpen},
+			{wantErr: ErrCircuitOpen, rejected: true, wantState: CircuitOpen},
+		}},
+		{name: "canceled probe keeps the circuit half open", threshold: 1, steps: []step{
+			{result: unavailable, wantErr: ErrUnavailable, wantState: CircuitOpen},
+			{wait: openDuration, result: context.Canceled, wantErr: context.Canceled, wantState: CircuitHalfOpen},
+			{wantState: CircuitClosed},
+		}},
+		{name: "disabled circuit breaker", threshold: 0, steps: []step{
+			{result: unavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+			{result: unavailable, wantErr: ErrUnavailable, wantState: CircuitClosed},
+			{wantState: CircuitClosed},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := make([]error, 0, len(test.steps))
+			for _, step := range test.steps {
+				if !step.rejected {
+					results = append(results, step.result)
+				}
+			}
+			repository := newFakeRepository(getResults(results...))
+			resilience := NewResilience(&ResilienceConfig{
+				Repository:       repository,
+				MaxAttempts:      1,
+				FailureThreshold: test.threshold,
+				OpenDuration:     openDuration,
+			})
+			for i, step := range test.steps {
+				time.Sleep(step.wait)
+				calls := repository.Calls("a")
+				_, err := resilience.Get(context.Background(), "a")
+				if step.wantErr == nil && err != nil {
+					t.Fatalf("step %d: unexpected error: %v", i, err)
+				}
+				if step.wantErr != nil && !errors.Is(err, step.wantErr) {
+					t.Fatalf("step %d: error = %v, want %v", i, err, step.wantErr)
+				}
+				if rejected := repository.Calls("a") == calls; rejected != step.rejected {
+					t.Errorf("step %d: rejected = %v, want %v", i, rejected, step.rejected)
+				}
+				if state := resilience.State(); state != step.wantState {
+					t.Errorf("step %d: state = %s, want %s", i, state, step.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestResilienceHalfOpenSingleProbe(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	repository := newFakeRepository(func(ctx context.Context, id string) (*models.Event, error) {
+		if id == "probe" {
+			close(started)
+			<-release
+		}
+		var err error
+		once.Do(func() { err = newError(ErrUnavailable, "get", nil) })
+		if err != nil {
+			return nil, err
+		}
+		return getEvent(ctx, id)
+	})
+	resilience := NewResilience(&ResilienceConfig{
+		Repository:       repository,
+		MaxAttempts:      1,
+		FailureThreshold: 1,
+		OpenDuration:     time.Millisecond,
+	})
+	if _, err := resilience.Get(context.Background(), "a"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("error = %v, want %v", err, ErrUnavailable)
+	}
+	time.Sleep(2 * time.Millisecond)
+	done := make(chan error)
+	go func() {
+		_, err := resilience.Get(context.Background(), "probe")
+		done <- err
+	}()
+	<-started
+	// enquanto o teste está em andamento as demais operações são rejeitadas
+	_, err := resilience.Get(context.Background(), "a")
+	if !errors.Is(err, ErrCircuitOpen) || RetryAfter(err) <= 0 {
+		t.Errorf("error = %v, retry after %s, want %v with retry after", err, RetryAfter(err), ErrCircuitOpen)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if state := resilience.State(); state != CircuitClosed {
+		t.Errorf("state = %s, want %s", state, CircuitClosed)
+	}
+}
+
+func TestResilienceRetries(t *testing.T) {
+	unavailable := newError(ErrUnavailable, "get", nil)
+	throttled := newError(ErrThrottled, "get", nil)
+	tests := []struct {
+		name        string
+		maxAttempts int
+		results     []error
+		wantErr     error
+		wantCalls   int
+		wantRetried bool
+	}{
+		{name: "transient error is retried", maxAttempts: 3, results: []error{throttled, unavailable}, wantCalls: 3, wantRetried: true},
+		{name: "attempts are limited", maxAttempts: 3, results: []error{unavailable, unavailable, unavailable, unavailable}, wantErr: ErrUnavailable, wantCalls: 3, wantRetried: true},
+		{name: "non transient error is not retried", maxAttempts: 3, results: []error{newError(ErrValidation, "get", nil)}, wantErr: ErrValidation, wantCalls: 1, wantRetried: true},
+		{name: "single attempt keeps repository retries", maxAttempts: 1, results: []error{unavailable}, wantErr: ErrUnavailable, wantCalls: 1, wantRetried: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := getResults(test.results...)
+			var retried bool
+			repository := newFakeRepository(func(ctx context.Context, id string) (*models.Event, error) {
+				retried = isRetriedAttempt(ctx)
+				return results(ctx, id)
+			})
+			resilience := NewResilience(&ResilienceConfig{
+				Repository:       repository,
+				MaxAttempts:      test.maxAttempts,
+				FailureThreshold: 10,
+				OpenDuration:     time.Minute,
+			})
+			_, err := resilience.Get(context.Background(), "a")
+			if test.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Fatalf("error = %v, want %v", err, test.wantErr)
+			}
+			if calls := repository.Calls("a"); calls != test.wantCalls {
+				t.Errorf("repository calls = %d, want %d", calls, test.wantCalls)
+			}
+			if retried != test.wantRetried {
+				t.Errorf("retried attempt = %v, want %v", retried, test.wantRetried)
+			}
+		})
+	}
+}
+
+func TestResilienceTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		// tempo limite de cada tentativa e prazo do chamador, zero sem prazo
+		timeout  time.Duration
+		deadline time.Duration
+		cancel   bool
+		// erro esperado, se ele é ErrUnavailable e o estado do circuito após a operação
+		wantErr         error
+		wantUnavailable bool
+		wantCalls       int
+		wantState       string
+	}{
+		{name: "attempt timeout is unavailable", timeout: 5 * time.Millisecond, wantErr: context.DeadlineExceeded, wantUnavailable: true, wantCalls: 2, wantState: CircuitOpen},
+		{name: "caller deadline is not a failure", timeout: time.Minute, deadline: 5 * time.Millisecond, wantErr: context.DeadlineExceeded, wantCalls: 1, wantState: CircuitClosed},
+		{name: "caller deadline before attempt timeout", timeout: 5 * time.Millisecond, deadline: 5 * time.Millisecond, wantErr: context.DeadlineExceeded, wantCalls: 1, wantState: CircuitClosed},
+		{name: "caller cancellation is not a failure", timeout: time.Minute, cancel: true, wantErr: context.Canceled, wantCalls: 1, wantState: CircuitClosed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := newFakeRepository(getBlocked)
+			resilience := NewResilience(&ResilienceConfig{
+				Repository:       repository,
+				Timeout:          test.timeout,
+				MaxAttempts:      2,
+				FailureThreshold: 2,
+				OpenDuration:     time.Minute,
+			})
+			ctx := context.Background()
+			if test.deadline > 0 {
+				var cancel context.CancelFunc
+				// o prazo do chamador termina antes do tempo limite da tentativa
+				ctx, cancel = context.WithDeadline(ctx, time.Now().Add(test.deadline-time.Millisecond))
+				defer cancel()
+			}
+			if test.cancel {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				time.AfterFunc(5*time.Millisecond, cancel)
+			}
+			_, err := resilience.Get(ctx, "a")
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("error = %v, want %v", err, test.wantErr)
+			}
+			if unavailable := errors.Is(err, ErrUnavailable); unavailable != test.wantUnavailable {
+				t.Errorf("unavailable = %v, want %v", unavailable, test.wantUnavailable)
+			}
+			if calls := repository.Calls("a"); calls != test.wantCalls {
+				t.Errorf("repository calls = %d, want %d", calls, test.wantCalls)
+			}
+			if state := resilience.State(); state != test.wantState {
+				t.Errorf("state = %s, want %s", state, test.wantState)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"api/interfaces"
+	"api/repositories"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// Carrega e valida as configurações da aplicação em camadas: valores padrão,
+// arquivo JSON ou YAML, variáveis de ambiente EVENTS_* e flags da linha de comando,
+// aplicando o nível de log configurado.
+// O arquivo é o informado em --config ou EVENTS_CONFIG; sem eles é usado o
+// config.json ou config.yaml ao lado do executável, se existir.
+func loadApplicationConfig(flags *configFlags) (*Config, error) {
+	file, err := configFile(flags)
+	if err != nil {
+		return nil, err
+	}
+	cfg := NewConfig("")
+	if file != "" {
+		if cfg, err = LoadConfig(file); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.LoadEnv(os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("invalid environment, %w", err)
+	}
+	if err := flags.apply(cfg); err != nil {
+		return nil, fmt.Errorf("invalid flags, %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration, %w", err)
+	}
+	logLevel.Set(cfg.Level())
+	return cfg, nil
+}
+
+// Retorna o arquivo de configuração a ser carregado ou vazio se não houver.
+func configFile(flags *configFlags) (string, error) {
+	if flags.file != "" {
+		return flags.file, nil
+	}
+	if file, ok := os.LookupEnv("EVENTS_CONFIG"); ok && file != "" {
+		return file, nil
+	}
+	currentDirectory, err := filepath.Abs(filepath.Dir(os.Args[0]))
+	if err != nil {
+		return "", err
+	}
+	for _, name := range []string{"config.json", "config.yaml", "config.yml"} {
+		file := filepath.Join(currentDirectory, name)
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+	return "", nil
+}
+
+// Inicializa a telemetria e direciona o log padrão para a saída padrão no formato
+// informado e para o OpenTelemetry, quando o exportador de logs estiver habilitado.
+// Sem nenhum dos dois o log padrão continua na saída de erro.
+// Retorna as funções de encerramento e de descarga da telemetria.
+func setupTelemetry(ctx context.Context, options telemetryOptions, logFormat string) (func(ctx context.Context) error, func(ctx context.Context) error, error) {
+	shutdown, flush, err := setupOTelSDK(ctx, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to setup OTel SDK: %w", err)
+	}
+	var sinks []slog.Handler
+	if handler := newWriterHandler(logFormat, os.Stdout); handler != nil {
+		sinks = append(sinks, handler)
+	}
+	if _, ok := global.GetLoggerProvider().(*sdklog.LoggerProvider); ok {
+		sinks = append(sinks, otelslog.NewHandler(os.Getenv("OTEL_SERVICE_NAME")))
+	}
+	if len(sinks) > 0 {
+		slog.SetDefault(slog.New(newLevelHandler(logLevel, newFanoutHandler(sinks...))))
+	}
+	return shutdown, flush, nil
+}
+
+// Encerra a telemetria com tempo limite, registrando o erro se houver.
+func shutdownTelemetry(shutdown func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		slog.Error("failed to shutdown OTel SDK", "error", err)
+	}
+}
+
+// Cria o cliente do DynamoDB com as credenciais padrão da AWS, instrumentado
+// com OpenTelemetry abaixo dos spans do repositório.
+func newDynamoDBClient(ctx context.Context) (*dynamodb.Client, error) {
+	sdkConfig, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instrumentAWSConfig(&sdkConfig)
+	return dynamodb.NewFromConfig(sdkConfig), nil
+}
+
+// Cria o repositório de dados configurado.
+func newRepository(ctx context.Context, cfg *Config) (interfaces.Repository, error) {
+	ttl := time.Duration(cfg.RecordTTLMinutes) * time.Minute
+	switch cfg.RepositoryKind {
+	case RepositoryDynamoDB:
+		client, err := newDynamoDBClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cfg.DynamoDBClient = client
+		return repositories.NewDynamoDBRepository(&repositories.DynamoDBConfig{
+			Client: client,
+			Table:  cfg.Table,
+			TTL:    ttl,
+		}), nil
+	default:
+		return repositories.NewMemoryDB(&repositories.MemoryDBConfig{
+			TTL: ttl,
+		}), nil
+	}
+}
+
+// Encerra o repositório com tempo limite, descarregando os dados pendentes.
+func closeRepository(repository interfaces.Repository) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := repository.Close(ctx); err != nil {
+		slog.Error("failed to close repository", "error", err)
+	}
+}
+
+// Cria o repositório de dados configurado, garantindo que ele exista.
+// As operações passam pela camada de resiliência (tempo limite, novas tentativas e
+// circuit breaker) e, com cache_size maior que zero, as consultas por id passam antes
+// pelo cache de leitura, para que os acertos não dependam do estado do circuito.
+func setupRepository(ctx context.Context, cfg *Config) error {
+	repository, err := newRepository(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if err := repository.Create(ctx); err != nil {
+		return fmt.Errorf("failed to create repository: %w", err)
+	}
+	cfg.Resilience = repositories.NewResilience(&repositories.ResilienceConfig{
+		Repository:       repository,
+		Timeout:          time.Duration(cfg.RepositoryTimeoutMs) * time.Millisecond,
+		BatchTimeout:     time.Duration(cfg.RepositoryBatchTimeoutMs) * time.Millisecond,
+		MaxAttempts:      cfg.RepositoryRetryAttempts,
+		BaseDelay:        time.Duration(cfg.RepositoryRetryBaseDelayMs) * time.Millisecond,
+		MaxDelay:         time.Duration(cfg.RepositoryRetryMaxDelayMs) * time.Millisecond,
+		FailureThreshold: cfg.CircuitFailureThreshold,
+		OpenDuration:     time.Duration(cfg.CircuitOpenSeconds) * time.Second,
+	})
+	repository = cfg.Resilience
+	if cfg.CacheSize > 0 {
+		repository = repositories.NewCache(&repositories.CacheConfig{
+			Repository:  repository,
+			Size:        cfg.CacheSize,
+			TTL:         time.Duration(cfg.CacheTTLSeconds) * time.Second,
+			NegativeTTL: time.Duration(cfg.CacheNegativeTTLSeconds) * time.Second,
+		})
+	}
+	cfg.Repository = repository
+	return nil
+}
@@ -0,0 +1,119 @@
+package telemetry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// emite apenas as convenções semânticas atuais do OpenTelemetry
+	SemConvNew = "new"
+	// emite apenas as convenções anteriores, para painéis e alertas ainda não migrados
+	SemConvOld = "old"
+	// emite as duas convenções durante a transição
+	SemConvDup = "dup"
+)
+
+// convenções semânticas emitidas nos spans e métricas
+var semConv atomic.Value
+
+func init() {
+	semConv.Store(SemConvNew)
+}
+
+// Define as convenções semânticas emitidas nos spans e métricas.
+// Deve ser chamada na inicialização, antes da criação dos spans e métricas.
+func SetSemConv(mode string) error {
+	switch mode {
+	case SemConvNew, SemConvOld, SemConvDup:
+		semConv.Store(mode)
+		return nil
+	}
+	return fmt.Errorf("unknown semantic conventions %q, expected %s, %s or %s", mode, SemConvNew, SemConvOld, SemConvDup)
+}
+
+// Indica se as convenções atuais devem ser emitidas.
+func emitNew() bool {
+	return semConv.Load() != SemConvOld
+}
+
+// Indica se as convenções anteriores devem ser emitidas.
+func emitOld() bool {
+	return semConv.Load() != SemConvNew
+}
+
+// Retorna os atributos de uma requisição HTTP atendida pelo servidor.
+func HTTPServerAttributes(method string, route string, statusCode int) []attribute.KeyValue {
+	method = strings.ToUpper(method)
+	attrs := []attribute.KeyValue{attribute.String("http.route", route)}
+	if emitNew() {
+		attrs = append(attrs,
+			attribute.String("http.request.method", method),
+			attribute.Int("http.response.status_code", statusCode),
+		)
+	}
+	if emitOld() {
+		attrs = append(attrs,
+			attribute.String("http.method", method),
+			attribute.String("http.status_code", strconv.Itoa(statusCode)),
+		)
+	}
+	return attrs
+}
+
+// Retorna os atributos de uma operação de banco de dados sobre a coleção (tabela) informada.
+func DBAttributes(system string, collection string, operation string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 6)
+	if emitNew() {
+		attrs = append(attrs,
+			attribute.String("db.system.name", system),
+			attribute.String("db.collection.name", collection),
+			attribute.String("db.operation.name", operation),
+		)
+		if system == "aws.dynamodb" {
+			attrs = append(attrs, attribute.StringSlice("aws.dynamodb.table_names", []string{collection}))
+		}
+	}
+	if emitOld() {
+		attrs = append(attrs,
+			attribute.String("db.system", system),
+			attribute.String("db.name", collection),
+			attribute.String("db.operation", operation),
+		)
+	}
+	return attrs
+}
+
+// Retorna os atributos das métricas de uma operação de banco de dados.
+func DBMetricAttributes(system string, operation string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 4)
+	if emitNew() {
+		attrs = append(attrs,
+			attribute.String("db.system.name", system),
+			attribute.String("db.operation.name", operation),
+		)
+	}
+	if emitOld() {
+		attrs = append(attrs,
+			attribute.String("db.system", system),
+			attribute.String("db.operation", operation),
+		)
+	}
+	return attrs
+}
+
+// Retorna o atributo com o texto da consulta ao banco de dados.
+func DBQueryText(statement string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 2)
+	if emitNew() {
+		attrs = append(attrs, attribute.String("db.query.text", statement))
+	}
+	if emitOld() {
+		attrs = append(attrs, attribute.String("db.statement", statement))
+	}
+	return attrs
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Mantém o resultado da última exportação de cada sinal da telemetria.
+type exporterStatus struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+// estado dos exportadores da telemetria
+var telemetryStatus = &exporterStatus{errs: make(map[string]error)}
+
+// Registra o resultado da exportação do sinal.
+func (s *exporterStatus) record(signal string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs[signal] = err
+}
+
+// Retorna erro se a última exportação de algum sinal falhou.
+func (s *exporterStatus) Check(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for _, signal := range []string{"traces", "metrics", "prometheus", "logs"} {
+		if err := s.errs[signal]; err != nil {
+			errs = append(errs, fmt.Errorf("%s exporter: %w", signal, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Exportador de rastreamento que registra o resultado das exportações.
+type statusSpanExporter struct {
+	trace.SpanExporter
+}
+
+// Exporta os spans registrando o resultado.
+func (e *statusSpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	telemetryStatus.record("traces", err)
+	return err
+}
+
+// Exportador de métricas que registra o resultado das exportações.
+type statusMetricExporter struct {
+	metric.Exporter
+}
+
+// Exporta as métricas registrando o resultado.
+func (e *statusMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, rm)
+	telemetryStatus.record("metrics", err)
+	return err
+}
+
+// Exportador de logs que registra o resultado das exportações.
+type statusLogExporter struct {
+	log.Exporter
+}
+
+// Exporta os logs registrando o resultado.
+func (e *statusLogExporter) Export(ctx context.Context, records []log.Record) error {
+	err := e.Exporter.Export(ctx, records)
+	telemetryStatus.record("logs", err)
+	return err
+}